@@ -0,0 +1,107 @@
+package filecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sync"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// ChecksumAlgorithm is an algorithm of the items' data checksums.
+type ChecksumAlgorithm string
+
+const (
+	// ChecksumCRC32C is a fast hardware-accelerated CRC-32 (Castagnoli) checksum, used by default.
+	ChecksumCRC32C ChecksumAlgorithm = "crc32c"
+
+	// ChecksumSHA256 is a SHA-256 cryptographic hash.
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+
+	// ChecksumNone disables the checksums and the data length verification.
+	ChecksumNone ChecksumAlgorithm = "none"
+)
+
+// newChecksumHash returns the hash of the algorithm.
+// Returns nil hash for the ChecksumNone and the empty algorithm of the items written without the checksums.
+func newChecksumHash(algo ChecksumAlgorithm) (hash.Hash, error) {
+	switch algo {
+	case "", ChecksumNone:
+		return nil, nil
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown checksum algorithm %s", algo)
+	}
+}
+
+// newVerifyingReader returns the reader of the item's decoded data.
+//
+// If the verify flag is set and the item has the checksum, the reader verifies the data's length and checksum,
+// returning the ErrCorrupted error on mismatch instead of the io.EOF;
+// the reader must read the data from its start in this case.
+// The onCorrupted function is called once, if the reading fails with any error except the io.EOF.
+func newVerifyingReader(m *meta, r io.ReadCloser, verify bool, onCorrupted func()) io.ReadCloser {
+	v := &verifyingReader{
+		key:         m.Key,
+		r:           r,
+		size:        m.Size,
+		onCorrupted: onCorrupted,
+	}
+
+	if !verify {
+		return util.NewReadCloser(v, r)
+	}
+
+	// The items with the unknown checksum algorithm are not verified.
+	if h, _ := newChecksumHash(ChecksumAlgorithm(m.SumAlgo)); h != nil {
+		v.hash = h
+		v.sum, _ = hex.DecodeString(m.Sum)
+	}
+
+	return util.NewReadCloser(v, r)
+}
+
+type verifyingReader struct {
+	key  string
+	r    io.Reader
+	hash hash.Hash
+	sum  []byte
+	size int64
+	read int64
+
+	onCorrupted func()
+	corrupted   sync.Once
+}
+
+func (v *verifyingReader) Read(p []byte) (n int, err error) {
+	n, err = v.r.Read(p)
+
+	if v.hash != nil {
+		v.read += int64(n)
+		_, _ = v.hash.Write(p[:n])
+
+		switch {
+		case v.read > v.size:
+			err = fmt.Errorf("%w: data is longer than %d bytes for key %s", ErrCorrupted, v.size, v.key)
+		case errors.Is(err, io.EOF) && v.read < v.size:
+			err = fmt.Errorf("%w: data is shorter than %d bytes for key %s", ErrCorrupted, v.size, v.key)
+		case errors.Is(err, io.EOF) && !bytes.Equal(v.hash.Sum(nil), v.sum):
+			err = fmt.Errorf("%w: checksum mismatch for key %s", ErrCorrupted, v.key)
+		}
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		v.corrupted.Do(v.onCorrupted)
+	}
+
+	return n, err
+}
@@ -0,0 +1,119 @@
+package filecache_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache_WhenDataCorrupted_ExpectErrCorrupted(t *testing.T) {
+	tests := map[string]func(data []byte) []byte{
+		"flipped": func(data []byte) []byte {
+			data[3] ^= 0xff
+
+			return data
+		},
+		"truncated": func(data []byte) []byte {
+			return data[:len(data)-1]
+		},
+		"appended": func(data []byte) []byte {
+			return append(data, '!')
+		},
+	}
+
+	for _, algo := range []filecache.ChecksumAlgorithm{"", filecache.ChecksumCRC32C, filecache.ChecksumSHA256} {
+		for name, corrupt := range tests {
+			target := getTarget(t, "checksum")
+			itemPath := filepath.Join(target, "test")
+			msg := string(algo) + " " + name
+
+			fc, err := filecache.New(target, filecache.InstanceOptions{
+				PathGenerator: filecache.FilteredKeyPath,
+				Checksum:      algo,
+			})
+			require.NoError(t, err)
+
+			_, err = fc.WriteData(context.Background(), "test", []byte("test value"))
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(itemPath)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(itemPath, corrupt(data), 0644))
+
+			res, err := fc.Read(context.Background(), "test")
+
+			assert.Nil(t, res, msg)
+			assert.ErrorIs(t, err, filecache.ErrCorrupted, msg)
+			assert.NoFileExists(t, itemPath, msg)
+			assert.NoFileExists(t, itemPath+"--meta", msg)
+		}
+	}
+}
+
+func TestFileCache_WhenOpenedDataCorrupted_ExpectErrCorruptedAtEOF(t *testing.T) {
+	target := getTarget(t, "checksum")
+	itemPath := filepath.Join(target, "test")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("test value"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(itemPath, []byte("TEST VALUE"), 0644))
+
+	res, err := fc.Open(context.Background(), "test")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+
+	data, err := io.ReadAll(res.Reader())
+
+	assert.Equal(t, "TEST VALUE", string(data))
+	assert.ErrorIs(t, err, filecache.ErrCorrupted)
+	assert.NoError(t, res.Reader().Close())
+	assert.NoFileExists(t, itemPath)
+
+	res, err = fc.Open(context.Background(), "test")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+}
+
+func TestFileCache_WhenChecksumNone_ExpectNotVerified(t *testing.T) {
+	target := getTarget(t, "checksum")
+	itemPath := filepath.Join(target, "test")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.FilteredKeyPath,
+		Checksum:      filecache.ChecksumNone,
+	})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("test value"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(itemPath, []byte("other"), 0644))
+
+	// The item written without the checksum is not verified by any instance.
+	fcVerifying, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	for _, instance := range []filecache.FileCache{fc, fcVerifying} {
+		res, err := instance.Read(context.Background(), "test")
+		require.NoError(t, err)
+
+		assert.True(t, res.Hit())
+		assert.Equal(t, "other", string(res.Data()))
+	}
+}
+
+func TestNew_WhenUnknownChecksum_ExpectError(t *testing.T) {
+	_, err := filecache.New(getTarget(t, "checksum"), filecache.InstanceOptions{Checksum: "md4"})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package filecache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+func (fc *fileCache) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	locksDir := filepath.Join(fc.dir, util.LocksDir)
+	items := make(map[string]struct{})
+	temps := make([]string, 0)
+
+	err := fc.env.storage.WalkDir(fc.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The dirs, which are removed or not readable, are not owned by the cache, e.g., in the system's temp dir.
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path == locksDir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasSuffix(path, util.MetaSuffix) {
+			if fc.clearItem(path) {
+				items[strings.TrimSuffix(path, util.MetaSuffix)] = struct{}{}
+			}
+
+			return nil
+		}
+
+		if _, ok := util.TempCacheFileTarget(path); ok {
+			temps = append(temps, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dirs := make([]string, 0, len(items))
+
+	for itemPath := range items {
+		dirs = append(dirs, filepath.Dir(itemPath))
+	}
+
+	for _, path := range temps {
+		fc.clearTemp(path, items)
+	}
+
+	util.RemoveEmptyDirs(fc.env.storage, fc.dir, append(dirs, fc.getTagsDirs()...)...)
+
+	return nil
+}
+
+// clearTemp removes the stale temporary file of the removed item's file or meta file, or of the tags index,
+// so the temporary files of other programs are kept. The temporary files of the writers in progress are kept too.
+func (fc *fileCache) clearTemp(path string, items map[string]struct{}) {
+	target, _ := util.TempCacheFileTarget(path)
+	itemPath := strings.TrimSuffix(target, util.MetaSuffix)
+
+	_, owned := items[itemPath]
+	if !owned && !strings.HasPrefix(path, fc.tags.dir+string(filepath.Separator)) {
+		return
+	}
+
+	if _, writing := fc.writing.Load(itemPath); writing {
+		return
+	}
+
+	_ = fc.env.storage.Remove(path)
+}
+
+// clearItem removes the item by its meta file path under the item's exclusive lock.
+// The meta files, which are not readable, are not considered as the cache-owned files and kept.
+func (fc *fileCache) clearItem(metaPath string) bool {
+	m, err := fc.env.readMeta("", metaPath)
+	if err != nil {
+		return false
+	}
+
+	unlock, err := fc.env.lock(m.Key)
+	if err != nil {
+		return false
+	}
+
+	defer unlock()
+
+	fc.tags.remove(m.Key, m.Tags)
+	util.DeleteCacheFiles(fc.env.storage, metaPath, strings.TrimSuffix(metaPath, util.MetaSuffix))
+
+	return true
+}
+
+// getTagsDirs returns the tags index dirs, so they are removed if empty after the clear.
+func (fc *fileCache) getTagsDirs() []string {
+	entries, err := fc.env.storage.ReadDir(fc.tags.dir)
+	if err != nil {
+		return nil
+	}
+
+	dirs := make([]string, 0, len(entries)+1)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(fc.tags.dir, entry.Name()))
+		}
+	}
+
+	return append(dirs, fc.tags.dir)
+}
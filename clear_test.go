@@ -0,0 +1,146 @@
+package filecache_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache_Clear(t *testing.T) {
+	target := getTarget(t, "clear")
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(target, "foreign.txt"), []byte("foreign"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(target, "foreign"), 0755))
+
+	fc, err := filecache.New(target)
+	require.NoError(t, err)
+
+	for _, key := range []string{"test1", "test2", "test3"} {
+		_, err := fc.WriteData(ctx, key, []byte(key), filecache.ItemOptions{Tags: []string{"tag"}})
+		require.NoError(t, err)
+	}
+
+	// Stale temporary file left by a crashed writer of the test1 item.
+	item1Path := filepath.Join(target, filecache.HashedKeySplitPath("test1"))
+	staleTmp := filepath.Join(filepath.Dir(item1Path), "."+filepath.Base(item1Path)+".12345.tmp")
+	require.NoError(t, os.WriteFile(staleTmp, []byte("stale"), 0644))
+
+	// Temporary file of another program, named as the cache's one.
+	require.NoError(t, os.WriteFile(filepath.Join(target, ".foreign.12345.tmp"), []byte("foreign"), 0644))
+
+	// Writer in progress.
+	w, err := fc.OpenWriter(ctx, "test4")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(w, "test4")
+	require.NoError(t, err)
+
+	require.NoError(t, fc.Clear(ctx))
+
+	require.NoError(t, w.Commit())
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	// The test4 item's dir is the only generated dir left.
+	item4Dir := filecache.HashedKeySplitPath("test4")[:2]
+
+	assert.ElementsMatch(t, []string{"foreign.txt", "foreign", ".foreign.12345.tmp", item4Dir}, names)
+	assert.NoFileExists(t, staleTmp)
+
+	assertHits(t, fc, map[string]bool{
+		"test1": false,
+		"test2": false,
+		"test3": false,
+		"test4": true,
+	})
+
+	removed, err := fc.InvalidateTags(ctx, "tag")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func TestFileCache_Clear_WhenContextCanceled_ExpectError(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "clear"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, fc.Clear(ctx), context.Canceled)
+}
+
+// unreadableDirStorage is the OS storage failing to read the dir's entries while walking.
+type unreadableDirStorage struct {
+	filecache.Storage
+
+	dir string
+}
+
+func (s unreadableDirStorage) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return s.Storage.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path != s.dir {
+			return fn(path, d, err)
+		}
+
+		if err := fn(path, d, nil); err != nil {
+			return err
+		}
+
+		if err := fn(path, d, &fs.PathError{Op: "readdirent", Path: path, Err: fs.ErrPermission}); err != nil {
+			return err
+		}
+
+		return filepath.SkipDir
+	})
+}
+
+func TestFileCache_Clear_WhenForeignDirs_ExpectKept(t *testing.T) {
+	target := getTarget(t, "clear")
+	ctx := context.Background()
+
+	foreignFiles := []string{
+		filepath.Join(target, "otherapp", "data", ".journal.42.tmp"),
+		filepath.Join(target, "otherapp", "data", "journal"),
+		filepath.Join(target, "private", "secret"),
+	}
+
+	for _, path := range foreignFiles {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("foreign"), 0644))
+	}
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.FilteredKeyPath,
+		Storage: unreadableDirStorage{
+			Storage: filecache.NewOSStorage(),
+			dir:     filepath.Join(target, "private"),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(ctx, "key", []byte("value"))
+	require.NoError(t, err)
+
+	require.NoError(t, fc.Clear(ctx))
+
+	for _, path := range foreignFiles {
+		assert.FileExists(t, path)
+	}
+
+	assertHits(t, fc, map[string]bool{"key": false})
+}
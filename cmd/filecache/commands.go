@@ -0,0 +1,577 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// itemInfo is the item's description printed by the commands.
+type itemInfo struct {
+	Key        string           `json:"key"`
+	Name       string           `json:"name,omitempty"`
+	Size       int64            `json:"size"`
+	CreatedAt  time.Time        `json:"created_at"`
+	AccessedAt *time.Time       `json:"accessed_at,omitempty"`
+	ExpiresAt  *time.Time       `json:"expires_at,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+	Fields     filecache.Values `json:"fields,omitempty"`
+}
+
+func newItemInfo(key string, createdAt time.Time, size int64, options *filecache.ItemOptions) itemInfo {
+	info := itemInfo{Key: key, Size: size, CreatedAt: createdAt}
+
+	if options == nil {
+		return info
+	}
+
+	info.Name = options.Name
+	info.Tags = options.Tags
+	info.Fields = options.Fields
+
+	if options.TTL > 0 {
+		expiresAt := createdAt.Add(options.TTL)
+		info.ExpiresAt = &expiresAt
+	}
+
+	return info
+}
+
+func scanEntryInfo(entry filecache.ScanEntry) itemInfo {
+	info := newItemInfo(entry.Key, entry.CreatedAt, entry.Size, entry.Options)
+	info.AccessedAt = &entry.AccessedAt
+
+	return info
+}
+
+// parseFlags parses the command's flags, returning the usageError on failure.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return usageError{msg: err.Error()}
+	}
+
+	return nil
+}
+
+func runLs(c *cli, args []string) error {
+	flags := c.newFlagSet("ls")
+
+	var fieldPairs stringsFlag
+
+	prefix := flags.String("prefix", "", "list the items with the keys starting with the prefix")
+	match := flags.String("match", "", "list the items with the keys matching the pattern (see path.Match)")
+	name := flags.String("name", "", "list the items with the names matching the pattern (see path.Match)")
+	expiresWithin := flags.Duration("expires-within", 0, "list the items expiring within the duration")
+	flags.Var(&fieldPairs, "field", "list the items with the field's value, in the K=V format")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	fields, err := parseFields(fieldPairs)
+	if err != nil {
+		return err
+	}
+
+	filter := func(info itemInfo) bool {
+		if len(info.Key) < len(*prefix) || info.Key[:len(*prefix)] != *prefix {
+			return false
+		}
+
+		if *match != "" && !matches(*match, info.Key) || *name != "" && !matches(*name, info.Name) {
+			return false
+		}
+
+		if *expiresWithin > 0 && (info.ExpiresAt == nil || time.Until(*info.ExpiresAt) > *expiresWithin) {
+			return false
+		}
+
+		for key, value := range fields {
+			if fmt.Sprint(info.Fields[key]) != value {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	items := make([]itemInfo, 0)
+
+	err = filecache.NewScanner(c.dir).Scan(func(entry filecache.ScanEntry) error {
+		if info := scanEntryInfo(entry); filter(info) {
+			items = append(items, info)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(items)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "KEY\tNAME\tSIZE\tCREATED\tEXPIRES")
+
+	for _, item := range items {
+		expires := "never"
+		if item.ExpiresAt != nil {
+			expires = item.ExpiresAt.Format(time.RFC3339)
+		}
+
+		created := item.CreatedAt.Format(time.RFC3339)
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", item.Key, item.Name, item.Size, created, expires)
+	}
+
+	return w.Flush()
+}
+
+func matches(pattern string, value string) bool {
+	ok, err := path.Match(pattern, value)
+
+	return err == nil && ok
+}
+
+func runGet(c *cli, args []string) error {
+	flags := c.newFlagSet("get")
+
+	output := flags.String("o", "", "write the data to the file instead of stdout")
+	printMeta := flags.Bool("meta", false, "print the item's metadata instead of the data")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return usageError{msg: "one key is expected"}
+	}
+
+	key := flags.Arg(0)
+
+	res, err := c.fc.Open(context.Background(), key)
+	if err != nil {
+		return err
+	}
+
+	if !res.Hit() {
+		return fmt.Errorf("%w: %s: %w", errMiss, key, res.MissReason())
+	}
+
+	defer func() {
+		_ = res.Reader().Close()
+	}()
+
+	if *printMeta {
+		info := newItemInfo(key, res.ModTime(), res.Size(), res.Options())
+
+		if c.json {
+			return c.printJSON(info)
+		}
+
+		return c.printInfo(info)
+	}
+
+	out := c.stdout
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		out = f
+	}
+
+	if _, err := io.Copy(out, res.Reader()); err != nil {
+		return fmt.Errorf("failed to read item %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func runPut(c *cli, args []string) error {
+	flags := c.newFlagSet("put")
+
+	var tags, fieldPairs stringsFlag
+
+	ttl := flags.Duration("ttl", 0, "item's TTL, eternal by default")
+	name := flags.String("name", "", "item's name")
+	compress := flags.Bool("gzip", false, "compress the item's data with gzip")
+	flags.Var(&tags, "tag", "item's tag")
+	flags.Var(&fieldPairs, "field", "item's field, in the K=V format")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usageError{msg: "key and optional file are expected"}
+	}
+
+	fields, err := parseFields(fieldPairs)
+	if err != nil {
+		return err
+	}
+
+	options := filecache.ItemOptions{Name: *name, TTL: *ttl, Tags: tags, Fields: fields}
+
+	if *compress {
+		options.Codec = filecache.NewGzipCodec(gzip.DefaultCompression)
+	}
+
+	input := c.stdin
+
+	if file := flags.Arg(1); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		input = f
+	}
+
+	written, err := c.fc.Write(context.Background(), flags.Arg(0), input, options)
+	if err != nil {
+		return err
+	}
+
+	return c.printResult("written", written)
+}
+
+func runRm(c *cli, args []string) error {
+	flags := c.newFlagSet("rm")
+
+	byPrefix := flags.Bool("prefix", false, "remove the items with the keys starting with the arguments")
+	byTag := flags.Bool("tag", false, "remove the items tagged with the arguments")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 || *byPrefix && *byTag {
+		return usageError{msg: "keys are expected, the -prefix and -tag flags can't be combined"}
+	}
+
+	ctx := context.Background()
+	removed := 0
+
+	switch {
+	case *byTag:
+		n, err := c.fc.InvalidateTags(ctx, flags.Args()...)
+		if err != nil {
+			return err
+		}
+
+		removed += n
+	case *byPrefix:
+		for _, prefix := range flags.Args() {
+			n, err := c.fc.InvalidatePrefix(ctx, prefix)
+			if err != nil {
+				return err
+			}
+
+			removed += n
+		}
+	default:
+		for _, key := range flags.Args() {
+			ok, err := removeKey(ctx, c.fc, key)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				removed++
+			}
+		}
+	}
+
+	return c.printResult("removed", removed)
+}
+
+// removeKey removes the valid item by the key, returns false if there is no such item.
+func removeKey(ctx context.Context, fc filecache.FileCache, key string) (ok bool, err error) {
+	res, err := fc.Open(ctx, key)
+	if err != nil || !res.Hit() {
+		return false, err
+	}
+
+	_ = res.Reader().Close()
+
+	if err := fc.Invalidate(ctx, key); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func runGC(c *cli, args []string) error {
+	flags := c.newFlagSet("gc")
+
+	maxSize := flags.Int64("max-size", 0, "maximum total size of the items in bytes, no limit by default")
+	maxItems := flags.Int("max-items", 0, "maximum number of the items, no limit by default")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	before, err := c.stats()
+	if err != nil {
+		return err
+	}
+
+	filecache.NewLRUGarbageCollector(c.dir, *maxSize, *maxItems).OnInstanceInit()
+
+	after, err := c.stats()
+	if err != nil {
+		return err
+	}
+
+	return c.printResult("removed", before.Items-after.Items)
+}
+
+// cacheStats are the cache statistics printed by the stats command.
+type cacheStats struct {
+	Items         int        `json:"items"`
+	EternalItems  int        `json:"eternal_items"`
+	Size          int64      `json:"size"`
+	Oldest        *time.Time `json:"oldest,omitempty"`
+	Newest        *time.Time `json:"newest,omitempty"`
+	LeastAccessed *time.Time `json:"least_accessed,omitempty"`
+}
+
+func (c *cli) stats() (*cacheStats, error) {
+	stats := &cacheStats{}
+
+	err := filecache.NewScanner(c.dir).Scan(func(entry filecache.ScanEntry) error {
+		stats.Items++
+		stats.Size += entry.Size
+
+		createdAt, accessedAt := entry.CreatedAt, entry.AccessedAt
+
+		if stats.Oldest == nil || createdAt.Before(*stats.Oldest) {
+			stats.Oldest = &createdAt
+		}
+
+		if stats.Newest == nil || createdAt.After(*stats.Newest) {
+			stats.Newest = &createdAt
+		}
+
+		if stats.LeastAccessed == nil || accessedAt.Before(*stats.LeastAccessed) {
+			stats.LeastAccessed = &accessedAt
+		}
+
+		if entry.Options == nil || entry.Options.TTL <= 0 {
+			stats.EternalItems++
+		}
+
+		return nil
+	})
+
+	return stats, err
+}
+
+func runStats(c *cli, args []string) error {
+	if err := parseFlags(c.newFlagSet("stats"), args); err != nil {
+		return err
+	}
+
+	stats, err := c.stats()
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(stats)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "items:\t%d\n", stats.Items)
+	fmt.Fprintf(w, "eternal items:\t%d\n", stats.EternalItems)
+	fmt.Fprintf(w, "size:\t%d\n", stats.Size)
+
+	for _, t := range []struct {
+		name  string
+		value *time.Time
+	}{{"oldest:", stats.Oldest}, {"newest:", stats.Newest}, {"least accessed:", stats.LeastAccessed}} {
+		if t.value != nil {
+			fmt.Fprintf(w, "%s\t%s\n", t.name, t.value.Format(time.RFC3339))
+		}
+	}
+
+	return w.Flush()
+}
+
+func runVerify(c *cli, args []string) error {
+	if err := parseFlags(c.newFlagSet("verify"), args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	keys := make([]string, 0)
+
+	err := filecache.NewScanner(c.dir).Scan(func(entry filecache.ScanEntry) error {
+		keys = append(keys, entry.Key)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verified := 0
+	corrupted := make([]string, 0)
+	unverified := make([]string, 0)
+
+	for _, key := range keys {
+		miss, err := verifyItem(ctx, c.fc, key)
+
+		switch {
+		case err != nil:
+			return err
+		case miss == nil:
+			verified++
+		case errors.Is(miss, filecache.ErrCorrupted) || errors.Is(miss, filecache.ErrDecryptionFailed):
+			corrupted = append(corrupted, key)
+		case !errors.Is(miss, filecache.ErrExpired):
+			// The item is not found by its key, e.g., the -path flag doesn't match the cache's path generator.
+			fmt.Fprintf(c.stderr, "unverified: %s: %s\n", key, miss)
+
+			unverified = append(unverified, key)
+		}
+	}
+
+	if c.json {
+		err := c.printJSON(map[string]any{"verified": verified, "corrupted": corrupted, "unverified": unverified})
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, key := range corrupted {
+			fmt.Fprintf(c.stdout, "corrupted: %s\n", key)
+		}
+
+		fmt.Fprintf(c.stdout, "verified: %d, corrupted: %d, unverified: %d\n",
+			verified, len(corrupted), len(unverified))
+	}
+
+	return verifyError(len(corrupted), len(unverified))
+}
+
+// verifyItem reads the item's data, returns the miss reason if the item is not read completely.
+func verifyItem(ctx context.Context, fc filecache.FileCache, key string) (miss error, err error) {
+	res, err := fc.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !res.Hit() {
+		return res.MissReason(), nil
+	}
+
+	_, err = io.Copy(io.Discard, res.Reader())
+	_ = res.Reader().Close()
+
+	if errors.Is(err, filecache.ErrCorrupted) || errors.Is(err, filecache.ErrDecryptionFailed) {
+		return err, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read item %s: %w", key, err)
+	}
+
+	return nil, nil
+}
+
+func verifyError(corrupted int, unverified int) error {
+	switch {
+	case corrupted > 0 && unverified > 0:
+		return fmt.Errorf("%d corrupted items found and removed, %d items not verified", corrupted, unverified)
+	case corrupted > 0:
+		return fmt.Errorf("%d corrupted items found and removed", corrupted)
+	case unverified > 0:
+		return fmt.Errorf("%d items not verified, check the -path flag", unverified)
+	}
+
+	return nil
+}
+
+func runClear(c *cli, args []string) error {
+	flags := c.newFlagSet("clear")
+
+	confirmed := flags.Bool("y", false, "confirm removing all the items")
+
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if !*confirmed {
+		return usageError{msg: "the -y flag is required to remove all the items"}
+	}
+
+	return c.fc.Clear(context.Background())
+}
+
+// printInfo prints the item's description.
+func (c *cli) printInfo(info itemInfo) error {
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "key:\t%s\n", info.Key)
+	fmt.Fprintf(w, "name:\t%s\n", info.Name)
+	fmt.Fprintf(w, "size:\t%d\n", info.Size)
+	fmt.Fprintf(w, "created:\t%s\n", info.CreatedAt.Format(time.RFC3339))
+
+	if info.ExpiresAt != nil {
+		fmt.Fprintf(w, "expires:\t%s\n", info.ExpiresAt.Format(time.RFC3339))
+	}
+
+	for _, tag := range info.Tags {
+		fmt.Fprintf(w, "tag:\t%s\n", tag)
+	}
+
+	for key, value := range info.Fields {
+		fmt.Fprintf(w, "field:\t%s=%v\n", key, value)
+	}
+
+	return w.Flush()
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// printResult prints the command's result counter.
+func (c *cli) printResult(name string, value any) error {
+	if c.json {
+		return c.printJSON(map[string]any{name: value})
+	}
+
+	_, err := fmt.Fprintf(c.stdout, "%s: %v\n", name, value)
+
+	return err
+}
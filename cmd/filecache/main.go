@@ -0,0 +1,207 @@
+// Command filecache is a tool to inspect and manage the FileCache directories.
+//
+// Usage:
+//
+//	filecache [-dir DIR] [-path split|hashed|filtered] [-json] <command> [arguments]
+//
+// Commands:
+//
+//	ls      list the valid items
+//	get     write the item's data to stdout or file
+//	put     write the data from stdin or file to the item
+//	rm      remove the items by keys, prefixes or tags
+//	gc      remove the expired items and evict the items exceeding the limits
+//	stats   print the cache statistics
+//	verify  read all the items, removing the corrupted ones
+//	clear   remove all the items
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// errMiss is returned by the commands when the item is not found.
+var errMiss = errors.New("item not found")
+
+// pathGenerators are the built-in path generators by their names.
+var pathGenerators = map[string]filecache.PathGeneratorFn{
+	"split":    filecache.HashedKeySplitPath,
+	"hashed":   filecache.HashedKeyPath,
+	"filtered": filecache.FilteredKeyPath,
+}
+
+// cli is the command-line tool's environment.
+type cli struct {
+	fc     filecache.FileCache
+	dir    string
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"ls":     {usage: "ls [-prefix P] [-match PATTERN] [-name PATTERN] [-field K=V]... [-expires-within D]", run: runLs},
+	"get":    {usage: "get [-o FILE] [-meta] KEY", run: runGet},
+	"put":    {usage: "put [-ttl D] [-name N] [-tag T]... [-field K=V]... [-gzip] KEY [FILE]", run: runPut},
+	"rm":     {usage: "rm [-prefix | -tag] KEY...", run: runRm},
+	"gc":     {usage: "gc [-max-size BYTES] [-max-items N]", run: runGC},
+	"stats":  {usage: "stats", run: runStats},
+	"verify": {usage: "verify", run: runVerify},
+	"clear":  {usage: "clear -y", run: runClear},
+}
+
+// run runs the tool with the arguments, returning the exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("filecache", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		printUsage(flags, stderr)
+	}
+
+	dir := flags.String("dir", "", "cache directory (required)")
+	pathName := flags.String("path", "split", "path generator of the cache: split, hashed or filtered")
+	asJSON := flags.Bool("json", false, "print the output as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok || *dir == "" {
+		flags.Usage()
+
+		return 2
+	}
+
+	pathGenerator, ok := pathGenerators[*pathName]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown path generator %s\n", *pathName)
+
+		return 2
+	}
+
+	fc, err := openCache(*dir, pathGenerator)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return 1
+	}
+
+	c := &cli{fc: fc, dir: *dir, json: *asJSON, stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if err := cmd.run(c, flags.Args()[1:]); err != nil {
+		var usageErr usageError
+
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "%s\nusage: filecache [flags] %s\n", usageErr.msg, cmd.usage)
+
+			return 2
+		}
+
+		fmt.Fprintln(stderr, err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// openCache opens the existing cache dir.
+// The New function creates the missing dir, so the mistyped dir would become an empty cache.
+func openCache(dir string, pathGenerator filecache.PathGeneratorFn) (filecache.FileCache, error) {
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("cache dir %s is not found", dir)
+	}
+
+	return filecache.New(dir, filecache.InstanceOptions{
+		PathGenerator: pathGenerator,
+		GC:            filecache.NewNopGarbageCollector(),
+	})
+}
+
+func printUsage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintln(w, "usage: filecache [flags] <command> [arguments]")
+	fmt.Fprintln(w, "\nflags:")
+	flags.PrintDefaults()
+	fmt.Fprintln(w, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// usageError is returned by the commands on the invalid arguments.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// newFlagSet returns the command's flags set.
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+
+	return flags
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+
+	return nil
+}
+
+// parseFields parses the K=V pairs.
+func parseFields(pairs []string) (filecache.Values, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	fields := make(filecache.Values, len(pairs))
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, usageError{msg: "invalid field " + pair}
+		}
+
+		fields[key] = value
+	}
+
+	return fields, nil
+}
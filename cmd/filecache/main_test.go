@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cliResult struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func runCLI(t *testing.T, stdin string, args ...string) cliResult {
+	t.Helper()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	code := run(args, strings.NewReader(stdin), stdout, stderr)
+
+	return cliResult{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func TestCLI(t *testing.T) {
+	dir := t.TempDir()
+
+	res := runCLI(t, "first data", "-dir", dir, "put", "-name", "first.txt", "-tag", "t1", "-field", "kind=doc", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "written: 10\n", res.stdout)
+
+	res = runCLI(t, "second data", "-dir", dir, "put", "-gzip", "-ttl", "1h", "k2")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	res = runCLI(t, "", "-dir", dir, "get", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "first data", res.stdout)
+
+	res = runCLI(t, "", "-dir", dir, "get", "k2")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "second data", res.stdout)
+
+	res = runCLI(t, "", "-dir", dir, "-json", "get", "-meta", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	var info itemInfo
+
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &info))
+	assert.Equal(t, "k1", info.Key)
+	assert.Equal(t, "first.txt", info.Name)
+	assert.Equal(t, int64(10), info.Size)
+	assert.Equal(t, []string{"t1"}, info.Tags)
+	assert.Nil(t, info.ExpiresAt)
+
+	res = runCLI(t, "", "-dir", dir, "get", "unknown")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "item not found")
+
+	res = runCLI(t, "", "-dir", dir, "ls")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "k1")
+	assert.Contains(t, res.stdout, "k2")
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: nil, expected: []string{"k1", "k2"}},
+		{args: []string{"-prefix", "k2"}, expected: []string{"k2"}},
+		{args: []string{"-match", "k?"}, expected: []string{"k1", "k2"}},
+		{args: []string{"-name", "*.txt"}, expected: []string{"k1"}},
+		{args: []string{"-field", "kind=doc"}, expected: []string{"k1"}},
+		{args: []string{"-expires-within", "2h"}, expected: []string{"k2"}},
+	}
+
+	for _, test := range tests {
+		res = runCLI(t, "", append([]string{"-dir", dir, "-json", "ls"}, test.args...)...)
+		require.Equal(t, 0, res.code, res.stderr)
+
+		var items []itemInfo
+
+		require.NoError(t, json.Unmarshal([]byte(res.stdout), &items))
+
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+
+		assert.ElementsMatch(t, test.expected, keys, test.args)
+	}
+
+	res = runCLI(t, "", "-dir", dir, "-json", "stats")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	var stats cacheStats
+
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &stats))
+	assert.Equal(t, 2, stats.Items)
+	assert.Equal(t, 1, stats.EternalItems)
+
+	res = runCLI(t, "", "-dir", dir, "verify")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "verified: 2, corrupted: 0, unverified: 0")
+
+	res = runCLI(t, "", "-dir", dir, "rm", "-tag", "t1")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "removed: 1\n", res.stdout)
+
+	res = runCLI(t, "", "-dir", dir, "clear")
+	assert.Equal(t, 2, res.code)
+
+	res = runCLI(t, "", "-dir", dir, "clear", "-y")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	res = runCLI(t, "", "-dir", dir, "-json", "stats")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, `"items": 0`)
+}
+
+func TestCLI_PutFileAndRemoveKeys(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "src.txt")
+
+	require.NoError(t, os.WriteFile(src, []byte("file data"), 0o600))
+
+	res := runCLI(t, "", "-dir", dir, "-path", "filtered", "put", "k1", src)
+	require.Equal(t, 0, res.code, res.stderr)
+
+	res = runCLI(t, "", "-dir", dir, "-path", "filtered", "-json", "put", "k2", "-")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.JSONEq(t, `{"written": 0}`, res.stdout)
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+
+	res = runCLI(t, "", "-dir", dir, "-path", "filtered", "get", "-o", out, "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "file data", string(data))
+
+	res = runCLI(t, "", "-dir", dir, "-path", "filtered", "rm", "k1", "k2", "k3", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "removed: 2\n", res.stdout)
+
+	res = runCLI(t, "", "-dir", dir, "-path", "filtered", "get", "k1")
+	assert.Equal(t, 1, res.code)
+}
+
+func TestCLI_Usage(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := [][]string{
+		{},
+		{"ls"},
+		{"-dir", dir},
+		{"-dir", dir, "unknown"},
+		{"-dir", dir, "-path", "unknown", "ls"},
+		{"-dir", dir, "get"},
+		{"-dir", dir, "put"},
+		{"-dir", dir, "put", "-field", "invalid", "k1"},
+		{"-dir", dir, "rm"},
+		{"-dir", dir, "ls", "-unknown"},
+	}
+
+	for _, args := range tests {
+		res := runCLI(t, "", args...)
+
+		assert.Equal(t, 2, res.code, args)
+		assert.NotEmpty(t, res.stderr, args)
+	}
+}
+
+func TestCLI_VerifyCorrupted(t *testing.T) {
+	dir := t.TempDir()
+
+	res := runCLI(t, "valid data", "-dir", dir, "put", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, "--meta") {
+			return err
+		}
+
+		return os.WriteFile(path, []byte("wrong data"), 0o600)
+	})
+	require.NoError(t, err)
+
+	res = runCLI(t, "", "-dir", dir, "-json", "verify")
+	assert.Equal(t, 1, res.code)
+	assert.JSONEq(t, `{"verified": 0, "corrupted": ["k1"], "unverified": []}`, strings.SplitAfter(res.stdout, "}\n")[0])
+
+	res = runCLI(t, "", "-dir", dir, "get", "k1")
+	assert.Equal(t, 1, res.code)
+}
+
+func TestCLI_VerifyWrongPathGenerator(t *testing.T) {
+	dir := t.TempDir()
+
+	res := runCLI(t, "valid data", "-dir", dir, "put", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+
+	res = runCLI(t, "", "-dir", dir, "-path", "filtered", "-json", "verify")
+	assert.Equal(t, 1, res.code)
+	assert.JSONEq(t, `{"verified": 0, "corrupted": [], "unverified": ["k1"]}`, strings.SplitAfter(res.stdout, "}\n")[0])
+	assert.Contains(t, res.stderr, "unverified: k1")
+
+	res = runCLI(t, "", "-dir", dir, "get", "k1")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "valid data", res.stdout)
+}
+
+func TestCLI_WhenDirNotExists_ExpectError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mistyped")
+
+	res := runCLI(t, "", "-dir", dir, "ls")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "not found")
+	assert.NoDirExists(t, dir)
+}
@@ -0,0 +1,140 @@
+package filecache
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// Codec is a compression codec of the cache items' data.
+//
+// The codec's name is stored in the item's metadata, so the item is decompressed
+// by the codec with the same name on read. The codecs are looked up in the registry,
+// see the RegisterCodec function.
+type Codec interface {
+	// Name returns the unique name of the codec.
+	Name() string
+
+	// NewWriter returns the writer compressing the data written to it into the w.
+	// The returned writer is closed when all the data is written.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns the reader decompressing the data from the r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// RegisterCodec registers the codec to decompress the items compressed with it.
+//
+// The codecs of the InstanceOptions and ItemOptions are registered automatically,
+// so registration is only required to read the items written by another process.
+// The gzip codec is registered by default.
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[codec.Name()] = codec
+}
+
+// NewGzipCodec returns the gzip Codec with the compression level.
+// The level is one of the compress/gzip package's level constants.
+func NewGzipCodec(level int) Codec {
+	return &gzipCodec{level: level}
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		gzipCodecName: NewGzipCodec(gzip.DefaultCompression),
+	}
+)
+
+// registerCodecIfMissing registers the codec, if there is no codec with the same name.
+func registerCodecIfMissing(codec Codec) {
+	if codec == nil {
+		return
+	}
+
+	codecsMu.RLock()
+	_, ok := codecs[codec.Name()]
+	codecsMu.RUnlock()
+
+	if !ok {
+		RegisterCodec(codec)
+	}
+}
+
+// getCodec returns the registered codec by its name.
+// Returns nil codec if the name is empty.
+func getCodec(name string) (Codec, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("codec %s is not registered", name)
+	}
+
+	return codec, nil
+}
+
+const gzipCodecName = "gzip"
+
+type gzipCodec struct {
+	level int
+}
+
+func (c *gzipCodec) Name() string {
+	return gzipCodecName
+}
+
+func (c *gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (c *gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// decodeItem wraps the item's file reader with the decryptor and the codec's decoder.
+// The returned reader closes the file on Close.
+func decodeItem(m *meta, codec Codec, crypt *encryptor, f io.ReadCloser) (io.ReadCloser, error) {
+	var (
+		r       io.Reader = f
+		closers           = []io.Closer{f}
+		err     error
+	)
+
+	if m.KeyID != "" {
+		if crypt == nil {
+			return nil, ErrEncrypted
+		}
+
+		r, err = crypt.newReader(r, m.KeyID, m.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create decryptor for key %s: %w", m.Key, err)
+		}
+	}
+
+	if codec != nil {
+		dec, err := codec.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s decoder for key %s: %w", codec.Name(), m.Key, err)
+		}
+
+		r = dec
+		closers = append([]io.Closer{dec}, closers...)
+	}
+
+	if m.KeyID == "" && codec == nil {
+		return f, nil
+	}
+
+	return util.NewReadCloser(r, closers...), nil
+}
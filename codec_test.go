@@ -0,0 +1,99 @@
+package filecache_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flateCodec struct{}
+
+func (c flateCodec) Name() string {
+	return "test-flate"
+}
+
+func (c flateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.BestSpeed)
+}
+
+func (c flateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func TestFileCache_WhenCodec_ExpectCompressed(t *testing.T) {
+	target := getTarget(t, "codec")
+	value := strings.Repeat("compressible value ", 1000)
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.FilteredKeyPath,
+		Codec:         filecache.NewGzipCodec(gzip.BestCompression),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	n, err := fc.WriteData(ctx, "gzip", []byte(value))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(value)), n)
+
+	_, err = fc.WriteData(ctx, "flate", []byte(value), filecache.ItemOptions{Codec: flateCodec{}})
+	require.NoError(t, err)
+
+	{
+		raw, err := os.ReadFile(filepath.Join(target, "gzip"))
+		require.NoError(t, err)
+
+		assert.Less(t, len(raw), len(value)/10)
+		assert.Equal(t, []byte{0x1f, 0x8b}, raw[:2])
+	}
+
+	for key, codecName := range map[string]string{"gzip": "gzip", "flate": "test-flate"} {
+		res, err := fc.Read(ctx, key)
+		require.NoError(t, err)
+
+		assert.True(t, res.Hit())
+		assert.Equal(t, value, string(res.Data()))
+		assert.Equal(t, codecName, res.Options().Codec.Name())
+
+		openRes, err := fc.Open(ctx, key)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(openRes.Reader())
+		require.NoError(t, err)
+		require.NoError(t, openRes.Reader().Close())
+
+		assert.Equal(t, value, string(data))
+	}
+}
+
+func TestFileCache_WhenCodecDataCorrupted_ExpectMiss(t *testing.T) {
+	target := getTarget(t, "codec")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.FilteredKeyPath,
+		Codec:         filecache.NewGzipCodec(gzip.DefaultCompression),
+	})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value"))
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(target, "test"), bytes.Repeat([]byte{0}, 16), 0644)
+	require.NoError(t, err)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.False(t, res.Hit())
+	assert.NoFileExists(t, filepath.Join(target, "test"))
+}
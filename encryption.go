@@ -0,0 +1,356 @@
+package filecache
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// KeyProvider provides the AES keys to encrypt the cache items with.
+//
+// The ID of the key is stored with every encrypted item,
+// so the keys might be rotated: the new items are encrypted with the current key,
+// while the old items are decrypted with the key found by its ID.
+type KeyProvider interface {
+	// CurrentKey returns the key to encrypt the new items with and its ID.
+	// The key must be 16, 24, or 32 bytes long to select AES-128, AES-192, or AES-256.
+	CurrentKey() (id string, key []byte, err error)
+
+	// Key returns the key by its ID.
+	Key(id string) (key []byte, err error)
+}
+
+// NewStaticKeyProvider returns the KeyProvider with the fixed set of the keys.
+// Function arguments:
+//   - currentID: the ID of the key to encrypt the new items with;
+//   - keys: the keys by their IDs, including the current one and the previous ones to decrypt the old items.
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) KeyProvider {
+	copied := make(map[string][]byte, len(keys))
+
+	for id, key := range keys {
+		copied[id] = key
+	}
+
+	return &staticKeyProvider{currentID: currentID, keys: copied}
+}
+
+type staticKeyProvider struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+func (p *staticKeyProvider) CurrentKey() (id string, key []byte, err error) {
+	key, err = p.Key(p.currentID)
+
+	return p.currentID, key, err
+}
+
+func (p *staticKeyProvider) Key(id string) (key []byte, err error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncryptionKey, id)
+	}
+
+	return key, nil
+}
+
+const (
+	// encChunkSize is a size of the plaintext chunk encrypted as a single AES-GCM message.
+	encChunkSize = 64 * 1024
+	// encNoncePrefixSize is a size of the random nonce prefix written to the start of the encrypted file.
+	// The rest of the nonce is a 4-byte chunk counter and a 1-byte last chunk flag.
+	encNoncePrefixSize = 7
+)
+
+// newEncryptor creates an encryptor using the keys of the provider.
+// Returns nil if the provider is nil.
+func newEncryptor(keys KeyProvider) *encryptor {
+	if keys == nil {
+		return nil
+	}
+
+	return &encryptor{keys: keys, aeads: make(map[string]cipher.AEAD)}
+}
+
+// encryptor encrypts the items' data & meta with AES-GCM.
+//
+// The item's data is encrypted in the chunks using the STREAM construction:
+// every chunk's nonce consists of the random file's prefix, the chunk counter and the last chunk flag,
+// so the chunks can't be reordered, removed or truncated without failing the decryption.
+// The item's key is used as an additional authenticated data, binding the encrypted data to the key.
+type encryptor struct {
+	keys KeyProvider
+
+	mu    sync.Mutex
+	aeads map[string]cipher.AEAD
+}
+
+// currentAEAD returns the AEAD of the current key and the key's ID.
+func (e *encryptor) currentAEAD() (id string, aead cipher.AEAD, err error) {
+	id, key, err := e.keys.CurrentKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get current encryption key: %w", err)
+	}
+
+	aead, err = e.newAEAD(id, key)
+
+	return id, aead, err
+}
+
+// aead returns the AEAD of the key by its ID.
+func (e *encryptor) aead(id string) (cipher.AEAD, error) {
+	e.mu.Lock()
+	aead, ok := e.aeads[id]
+	e.mu.Unlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	key, err := e.keys.Key(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key %s: %w", id, err)
+	}
+
+	return e.newAEAD(id, key)
+}
+
+func (e *encryptor) newAEAD(id string, key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+	}
+
+	e.mu.Lock()
+	e.aeads[id] = aead
+	e.mu.Unlock()
+
+	return aead, nil
+}
+
+// seal encrypts the small data as a single message with the current key.
+// Returns the key's ID and the nonce followed by the ciphertext.
+func (e *encryptor) seal(data []byte) (id string, sealed []byte, err error) {
+	id, aead, err := e.currentAEAD()
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return id, aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts the data encrypted by the seal function.
+func (e *encryptor) open(id string, sealed []byte) ([]byte, error) {
+	aead, err := e.aead(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return data, nil
+}
+
+// newWriter returns the writer encrypting the item's data into the w with the current key.
+// Returns the ID of the key.
+func (e *encryptor) newWriter(w io.Writer, key string) (id string, wc io.WriteCloser, err error) {
+	id, aead, err := e.currentAEAD()
+	if err != nil {
+		return "", nil, err
+	}
+
+	prefix := make([]byte, encNoncePrefixSize)
+
+	if _, err := rand.Read(prefix); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	if _, err := w.Write(prefix); err != nil {
+		return "", nil, err
+	}
+
+	return id, &encWriter{
+		w:      w,
+		aead:   aead,
+		stream: newEncStream(prefix, key),
+		buf:    make([]byte, 0, encChunkSize),
+	}, nil
+}
+
+// newReader returns the reader decrypting the item's data from the r with the key of the ID.
+func (e *encryptor) newReader(r io.Reader, id string, key string) (io.Reader, error) {
+	aead, err := e.aead(id)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, encNoncePrefixSize)
+
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return &encReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		stream: newEncStream(prefix, key),
+		chunk:  make([]byte, encChunkSize+aead.Overhead()),
+	}, nil
+}
+
+// encStream is a nonce sequence of the encrypted chunks.
+type encStream struct {
+	nonce   []byte
+	aad     []byte
+	counter uint32
+}
+
+func newEncStream(prefix []byte, key string) *encStream {
+	nonce := make([]byte, encNoncePrefixSize+5)
+	copy(nonce, prefix)
+
+	return &encStream{nonce: nonce, aad: []byte(key)}
+}
+
+// next returns the nonce of the next chunk.
+func (s *encStream) next(last bool) ([]byte, error) {
+	if s.counter == ^uint32(0) {
+		return nil, errors.New("encrypted stream is too long")
+	}
+
+	binary.BigEndian.PutUint32(s.nonce[encNoncePrefixSize:], s.counter)
+	s.nonce[len(s.nonce)-1] = 0
+
+	if last {
+		s.nonce[len(s.nonce)-1] = 1
+	}
+
+	s.counter++
+
+	return s.nonce, nil
+}
+
+type encWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	stream *encStream
+	buf    []byte
+	sealed []byte
+}
+
+func (w *encWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// The full chunk is flushed only when there is more data, so the last chunk is always flushed on Close.
+		if len(w.buf) == encChunkSize {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+
+		c := copy(w.buf[len(w.buf):encChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+	}
+
+	return n, nil
+}
+
+func (w *encWriter) Close() error {
+	return w.flush(true)
+}
+
+func (w *encWriter) flush(last bool) error {
+	nonce, err := w.stream.next(last)
+	if err != nil {
+		return err
+	}
+
+	w.sealed = w.aead.Seal(w.sealed[:0], nonce, w.buf, w.stream.aad)
+	w.buf = w.buf[:0]
+
+	_, err = w.w.Write(w.sealed)
+
+	return err
+}
+
+type encReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	stream *encStream
+	chunk  []byte
+	plain  []byte
+	done   bool
+}
+
+func (r *encReader) Read(p []byte) (n int, err error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.plain)
+	r.plain = r.plain[n:]
+
+	return n, nil
+}
+
+// next reads & decrypts the next chunk.
+func (r *encReader) next() error {
+	n, err := io.ReadFull(r.r, r.chunk)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		// The last chunk is always written, so the data is truncated.
+		return ErrDecryptionFailed
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		r.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.r.Peek(1); errors.Is(err, io.EOF) {
+			r.done = true
+		}
+	}
+
+	nonce, err := r.stream.next(r.done)
+	if err != nil {
+		return err
+	}
+
+	r.plain, err = r.aead.Open(r.chunk[:0], nonce, r.chunk[:n], r.stream.aad)
+	if err != nil {
+		return ErrDecryptionFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package filecache_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testKey1 = bytes.Repeat([]byte{1}, 32)
+	testKey2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func newEncryptedTestCache(t *testing.T, target string, currentID string) filecache.FileCache {
+	fc, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.HashedKeyPath,
+		Encryption: filecache.NewStaticKeyProvider(currentID, map[string][]byte{
+			"k1": testKey1,
+			"k2": testKey2,
+		}),
+	})
+	require.NoError(t, err)
+
+	return fc
+}
+
+func TestFileCache_WhenEncrypted_ExpectDecrypted(t *testing.T) {
+	target := getTarget(t, "encryption")
+	fc := newEncryptedTestCache(t, target, "k1")
+	ctx := context.Background()
+
+	sizes := []int{0, 1, 64*1024 - 1, 64 * 1024, 64*1024 + 1, 3*64*1024 + 100}
+
+	for _, size := range sizes {
+		key := fmt.Sprintf("secret-key-%d", size)
+		value := make([]byte, size)
+
+		_, err := rand.Read(value)
+		require.NoError(t, err)
+
+		n, err := fc.WriteData(ctx, key, value, filecache.ItemOptions{Name: "secret name"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), n)
+
+		res, err := fc.Read(ctx, key)
+		require.NoError(t, err)
+
+		assert.True(t, res.Hit(), size)
+		assert.Equal(t, value, res.Data(), size)
+		assert.Equal(t, "secret name", res.Options().Name)
+	}
+
+	_, err := fc.WriteData(ctx, "plain-key", []byte("plain value"))
+	require.NoError(t, err)
+
+	item, err := os.ReadFile(filepath.Join(target, filecache.HashedKeyPath("plain-key")))
+	require.NoError(t, err)
+
+	meta, err := os.ReadFile(filepath.Join(target, filecache.HashedKeyPath("plain-key")+"--meta"))
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(item), "plain value")
+	assert.NotContains(t, string(meta), "plain-key")
+}
+
+func TestFileCache_WhenEncryptedAndCompressed_ExpectDecrypted(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "encryption"), filecache.InstanceOptions{
+		Codec:      filecache.NewGzipCodec(gzip.DefaultCompression),
+		Encryption: filecache.NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey1}),
+	})
+	require.NoError(t, err)
+
+	value := bytes.Repeat([]byte("value "), 100000)
+
+	_, err = fc.WriteData(context.Background(), "test", value, filecache.ItemOptions{Tags: []string{"tag"}})
+	require.NoError(t, err)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, value, res.Data())
+
+	removed, err := fc.InvalidateTags(context.Background(), "tag")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestFileCache_WhenKeyRotated_ExpectOldItemsReadable(t *testing.T) {
+	target := getTarget(t, "encryption")
+	ctx := context.Background()
+
+	fc1 := newEncryptedTestCache(t, target, "k1")
+
+	_, err := fc1.WriteData(ctx, "old", []byte("old value"))
+	require.NoError(t, err)
+
+	fc2 := newEncryptedTestCache(t, target, "k2")
+
+	_, err = fc2.WriteData(ctx, "new", []byte("new value"))
+	require.NoError(t, err)
+
+	assertData(t, fc2, "old", "old value")
+	assertData(t, fc2, "new", "new value")
+
+	// The instance without the k2 key can't read the new item.
+	fc3, err := filecache.New(target, filecache.InstanceOptions{
+		PathGenerator: filecache.HashedKeyPath,
+		Encryption:    filecache.NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey1}),
+	})
+	require.NoError(t, err)
+
+	assertData(t, fc3, "old", "old value")
+
+	res, err := fc3.Read(ctx, "new")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+	assert.ErrorIs(t, res.MissReason(), filecache.ErrUnknownEncryptionKey)
+
+	assertData(t, fc2, "new", "new value")
+}
+
+func TestFileCache_WhenEncryptedDataTampered_ExpectError(t *testing.T) {
+	target := getTarget(t, "encryption")
+	fc := newEncryptedTestCache(t, target, "k1")
+	ctx := context.Background()
+	itemPath := filepath.Join(target, filecache.HashedKeyPath("test"))
+
+	tamper := map[string]func(data []byte) []byte{
+		"flipped": func(data []byte) []byte {
+			data[len(data)/2] ^= 0xff
+
+			return data
+		},
+		"truncated": func(data []byte) []byte {
+			return data[:64*1024+30]
+		},
+	}
+
+	for name, fn := range tamper {
+		_, err := fc.WriteData(ctx, "test", bytes.Repeat([]byte{42}, 100*1024))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(itemPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(itemPath, fn(data), 0644))
+
+		res, err := fc.Read(ctx, "test")
+
+		assert.Nil(t, res, name)
+		assert.ErrorIs(t, err, filecache.ErrDecryptionFailed, name)
+		assert.NoFileExists(t, itemPath, name)
+	}
+}
+
+func TestFileCache_WhenEncryptedAndNoKeys_ExpectMissAndKept(t *testing.T) {
+	target := getTarget(t, "encryption")
+	ctx := context.Background()
+
+	fc1 := newEncryptedTestCache(t, target, "k1")
+
+	_, err := fc1.WriteData(ctx, "test", []byte("value"))
+	require.NoError(t, err)
+
+	fc2, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.HashedKeyPath})
+	require.NoError(t, err)
+
+	res, err := fc2.Read(ctx, "test")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+	assert.ErrorIs(t, res.MissReason(), filecache.ErrEncrypted)
+
+	assertData(t, fc1, "test", "value")
+
+	found := 0
+
+	err = filecache.NewScanner(target).Scan(func(entry filecache.ScanEntry) error {
+		found++
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, found)
+
+	err = filecache.NewScanner(target, filecache.InstanceOptions{
+		Encryption: filecache.NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey1}),
+	}).Scan(func(entry filecache.ScanEntry) error {
+		assert.Equal(t, "test", entry.Key)
+		found++
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, found)
+}
+
+func TestStaticKeyProvider(t *testing.T) {
+	p := filecache.NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey1})
+
+	id, key, err := p.CurrentKey()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", id)
+	assert.Equal(t, testKey1, key)
+
+	_, err = p.Key("unknown")
+
+	assert.ErrorIs(t, err, filecache.ErrUnknownEncryptionKey)
+}
+
+func assertData(t *testing.T, fc filecache.FileCache, key string, expected string) {
+	t.Helper()
+
+	res, err := fc.Read(context.Background(), key)
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit(), key)
+	assert.Equal(t, expected, string(res.Data()), key)
+}
@@ -0,0 +1,65 @@
+package filecache
+
+// itemsEnv is an environment of the FileCache instance's items,
+// shared with the scanners and the garbage collectors working with the instance's dir.
+// The nil itemsEnv is a valid environment of the OS storage without locks and encryption.
+type itemsEnv struct {
+	// locker locks the items, nil if the items are not locked.
+	locker *itemsLocker
+
+	// crypt encrypts the items, nil if the encryption is disabled.
+	crypt *encryptor
+
+	// storage stores the items' files, nil for the OS storage.
+	storage Storage
+}
+
+// envBinder is implemented by the built-in garbage collectors
+// to work with the items in the environment of the FileCache instance.
+type envBinder interface {
+	bindEnv(env *itemsEnv)
+}
+
+// lock locks the key exclusively, returns the function to unlock it.
+func (e *itemsEnv) lock(key string) (unlock func(), err error) {
+	if e == nil || e.locker == nil {
+		return func() {}, nil
+	}
+
+	return e.locker.lock(key)
+}
+
+// rlock locks the key for reading, returns the function to unlock it.
+func (e *itemsEnv) rlock(key string) (unlock func(), err error) {
+	if e == nil || e.locker == nil {
+		return func() {}, nil
+	}
+
+	return e.locker.rlock(key)
+}
+
+// locksFiles returns true if the items are locked across the processes.
+func (e *itemsEnv) locksFiles() bool {
+	return e != nil && e.locker != nil && e.locker.files != nil
+}
+
+// readMeta reads the item's meta file.
+func (e *itemsEnv) readMeta(key string, path string) (*meta, error) {
+	return readMeta(e.getStorage(), key, path, e.getCrypt())
+}
+
+func (e *itemsEnv) getCrypt() *encryptor {
+	if e == nil {
+		return nil
+	}
+
+	return e.crypt
+}
+
+func (e *itemsEnv) getStorage() Storage {
+	if e == nil || e.storage == nil {
+		return osStorage{}
+	}
+
+	return e.storage
+}
@@ -0,0 +1,35 @@
+package filecache
+
+import "errors"
+
+var (
+	// ErrNotFound is a miss reason of the item, which is not found in the cache.
+	ErrNotFound = errors.New("cache item not found")
+
+	// ErrExpired is a miss reason of the item, which TTL is expired.
+	ErrExpired = errors.New("cache item is expired")
+
+	// ErrCorruptedMeta is a miss reason of the item, which metadata is not readable.
+	ErrCorruptedMeta = errors.New("cache item meta is corrupted")
+
+	// ErrKeyMismatch is a miss reason of the item, which path is taken by another key's item,
+	// e.g., if the PathGenerator maps both keys to the same path.
+	// Also, it's returned by the FileCache's Invalidate function, not removing another key's item.
+	ErrKeyMismatch = errors.New("cache item path is taken by another key")
+
+	// ErrEncrypted is a miss reason of the encrypted item, read by the instance without the encryption.
+	ErrEncrypted = errors.New("cache item is encrypted, but no encryption is configured")
+
+	// ErrWriterClosed is returned when using the ItemWriter, which is already committed or aborted.
+	ErrWriterClosed = errors.New("item writer is already committed or aborted")
+
+	// ErrUnknownEncryptionKey is returned by the KeyProvider, if the key is not found by its ID.
+	ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+
+	// ErrDecryptionFailed is returned when the encrypted data is not authentic or truncated.
+	ErrDecryptionFailed = errors.New("decryption failed")
+
+	// ErrCorrupted is returned when reading the item's data, which length or checksum doesn't match the stored ones.
+	// Also, it's a miss reason of the item, which data or meta file is missing or not decodable.
+	ErrCorrupted = errors.New("cache item is corrupted")
+)
@@ -3,9 +3,16 @@ package filecache
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kukymbr/filecache/v2/internal/util"
@@ -14,6 +21,9 @@ import (
 const (
 	// TTLEternal is a TTL value for eternal cache.
 	TTLEternal = util.TTLEternal
+
+	// publishRetries is a number of the retries to open the item, which file is being published.
+	publishRetries = 3
 )
 
 // New creates a new FileCache instance with a specified target dir & options.
@@ -28,7 +38,17 @@ func New(targetDir string, options ...InstanceOptions) (FileCache, error) {
 		targetDir = os.TempDir()
 	}
 
-	if err := util.PrepareDir(targetDir); err != nil {
+	opt := InstanceOptions{}
+
+	if len(options) == 1 {
+		opt = options[0]
+	}
+
+	if opt.Storage == nil {
+		opt.Storage = NewOSStorage()
+	}
+
+	if err := util.PrepareDir(opt.Storage, targetDir); err != nil {
 		return nil, err
 	}
 
@@ -36,24 +56,11 @@ func New(targetDir string, options ...InstanceOptions) (FileCache, error) {
 		dir:           targetDir,
 		ttlDefault:    TTLEternal,
 		pathGenerator: HashedKeySplitPath,
-		gc:            NewProbabilityGarbageCollector(targetDir, 1, 100),
-		keysLocker:    util.NewKeysLocker(),
+		flights:       util.NewFlightGroup(),
 	}
 
-	if len(options) == 1 {
-		if options[0].DefaultTTL != 0 {
-			fc.ttlDefault = options[0].DefaultTTL
-		}
-
-		if options[0].GC != nil {
-			fc.gc = options[0].GC
-		} else if options[0].GCDivisor != 0 {
-			fc.gc = NewProbabilityGarbageCollector(targetDir, 1, options[0].GCDivisor)
-		}
-
-		if options[0].PathGenerator != nil {
-			fc.pathGenerator = util.PathGeneratorFn(options[0].PathGenerator)
-		}
+	if err := fc.init(opt); err != nil {
+		return nil, err
 	}
 
 	go fc.gc.OnInstanceInit()
@@ -72,11 +79,22 @@ type FileCache interface {
 	GetPath() string
 
 	// Write writes data from the reader to the cache file.
+	//
+	// The data is staged in temporary files and published only when completely written,
+	// so readers see either the previous complete item or the new one.
+	// If writing the data fails, the previous item is kept.
+	// If publishing the written files fails, the previous item might be removed.
 	Write(ctx context.Context, key string, reader io.Reader, options ...ItemOptions) (written int64, err error)
 
 	// WriteData writes data to the cache file.
 	WriteData(ctx context.Context, key string, data []byte, options ...ItemOptions) (written int64, err error)
 
+	// OpenWriter opens the ItemWriter to write the item's data into.
+	//
+	// The data is published to the cache on the writer's Commit or Close call and discarded on the Abort call.
+	// The key is locked until the writer is committed or aborted.
+	OpenWriter(ctx context.Context, key string, options ...ItemOptions) (w ItemWriter, err error)
+
 	// Open opens the reader with cached data.
 	//
 	// Returns no error on successful cache hit, on no hit, on invalid cache files.
@@ -89,97 +107,133 @@ type FileCache interface {
 	// Returns an error if failed to open or read an existing cache file or if context is done.
 	Read(ctx context.Context, key string) (result *ReadResult, err error)
 
+	// GetOrWrite opens the cached data or, on a cache miss, writes the data returned by the loader and opens it.
+	//
+	// Concurrent misses of the same key are de-duplicated inside the process:
+	// the loader is called once, and every waiter receives its own reader of the written item.
+	// The loader is called with the context of the caller, which triggered the load;
+	// if it's canceled, the waiters with the alive contexts retry the load.
+	// The waiters stop waiting when their own contexts are done, returning the context's error.
+	// If the reader returned by the loader implements io.Closer, it is closed after the write.
+	GetOrWrite(ctx context.Context, key string, loader LoaderFn) (result *OpenResult, err error)
+
 	// Invalidate removes data associated with a key from a cache.
+	// Returns the ErrKeyMismatch error if the key's path is taken by another key's item, keeping it.
 	Invalidate(ctx context.Context, key string) error
 
+	// InvalidateTags removes all the items having any of the tags, returns the number of removed items.
+	// The items are looked up by the tags index, without scanning the whole cache.
+	InvalidateTags(ctx context.Context, tags ...string) (removed int, err error)
+
+	// InvalidatePrefix removes all the items with keys starting with the prefix,
+	// returns the number of removed items.
+	// The whole cache is scanned, so this might be slow for the large caches.
+	InvalidatePrefix(ctx context.Context, prefix string) (removed int, err error)
+
+	// InvalidateMatch removes all the items matching the function, returns the number of removed items.
+	// The whole cache is scanned, so this might be slow for the large caches.
+	InvalidateMatch(ctx context.Context, match func(entry ScanEntry) bool) (removed int, err error)
+
+	// Clear removes all the items from the cache.
+	//
+	// Only the files owned by the cache are removed: the items with their meta files,
+	// the stale temporary files of the removed items and of the tags index,
+	// the tags index and the items' subdirectories, if they are empty.
+	// The dirs, which are not readable, are skipped. The lock files of the FileLocking option are kept.
+	// Writers of the other processes, which are in progress, might fail to publish their items.
+	Clear(ctx context.Context) error
+
 	// Close closes the FileCache instance.
 	Close() error
 }
 
+// LoaderFn is a function returning the data to cache on a cache miss.
+// Receives the context of the caller and returns the data reader with the item options.
+type LoaderFn func(ctx context.Context) (reader io.Reader, options ItemOptions, err error)
+
 type fileCache struct {
 	dir           string
 	pathGenerator util.PathGeneratorFn
 	ttlDefault    time.Duration
 	gc            GarbageCollector
+	codec         Codec
+	checksum      ChecksumAlgorithm
 
-	keysLocker *util.KeysLocker
-}
+	env     *itemsEnv
+	flights *util.FlightGroup
+	tags    *tagsIndex
 
-func (fc *fileCache) GetPath() string {
-	return fc.dir
+	// writing is a set of the items' paths with the writers in progress.
+	writing sync.Map
 }
 
-//nolint:funlen
-func (fc *fileCache) Write(
-	ctx context.Context,
-	key string,
-	reader io.Reader,
-	options ...ItemOptions,
-) (written int64, err error) {
-	if err := ctx.Err(); err != nil {
-		return 0, err
+// init applies the instance options.
+func (fc *fileCache) init(opt InstanceOptions) error {
+	if opt.DefaultTTL != 0 {
+		fc.ttlDefault = opt.DefaultTTL
 	}
 
-	defer func() {
-		go fc.gc.OnOperation()
-	}()
+	if opt.PathGenerator != nil {
+		fc.pathGenerator = util.PathGeneratorFn(opt.PathGenerator)
+	}
 
-	opt := ItemOptions{}
+	fc.codec = opt.Codec
+	registerCodecIfMissing(fc.codec)
 
-	if len(options) > 0 {
-		opt = options[0]
+	fc.checksum = ChecksumCRC32C
+	if opt.Checksum != "" {
+		fc.checksum = opt.Checksum
 	}
 
-	fc.keysLocker.Lock(key)
-	defer fc.keysLocker.Unlock(key)
-
-	meta := newMeta(key, &opt, fc.ttlDefault)
-	itemPath := fc.getItemPath(key, false, true)
-	metaPath := fc.getItemPath(key, true, true)
+	if _, err := newChecksumHash(fc.checksum); err != nil {
+		return err
+	}
 
-	itemF, err := util.CreateCacheFile(key, itemPath)
+	gc, err := newInstanceGC(fc.dir, opt)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	defer func() {
-		_ = itemF.Close()
-	}()
+	if opt.FileLocking && !isOSStorage(opt.Storage) {
+		return fmt.Errorf("FileLocking option requires the OS storage")
+	}
 
-	metaF, err := util.CreateCacheFile(key, metaPath)
+	locker, err := newItemsLocker(fc.dir, opt.FileLocking)
 	if err != nil {
-		_ = itemF.Close()
-
-		util.DeleteCacheFiles(itemPath, "")
-
-		return 0, err
+		return err
 	}
 
-	defer func() {
-		_ = metaF.Close()
-	}()
-
-	undo := func() {
-		_ = itemF.Close()
-		_ = metaF.Close()
+	fc.gc = gc
+	fc.env = &itemsEnv{
+		locker:  locker,
+		crypt:   newEncryptor(opt.Encryption),
+		storage: opt.Storage,
+	}
+	fc.tags = newTagsIndex(fc.dir, fc.env)
 
-		util.DeleteCacheFiles(itemPath, metaPath)
+	if binder, ok := fc.gc.(envBinder); ok {
+		binder.bindEnv(fc.env)
 	}
 
-	if err := saveMeta(ctx, meta, metaF); err != nil {
-		undo()
+	return nil
+}
 
-		return 0, err
-	}
+func (fc *fileCache) GetPath() string {
+	return fc.dir
+}
 
-	n, err := util.CopyWithCtx(ctx, itemF, reader)
+func (fc *fileCache) Write(
+	ctx context.Context,
+	key string,
+	reader io.Reader,
+	options ...ItemOptions,
+) (written int64, err error) {
+	w, err := fc.OpenWriter(ctx, key, options...)
 	if err != nil {
-		undo()
-
 		return 0, err
 	}
 
-	return n, nil
+	return copyToWriter(ctx, w, reader)
 }
 
 func (fc *fileCache) WriteData(
@@ -193,7 +247,7 @@ func (fc *fileCache) WriteData(
 	return fc.Write(ctx, key, reader, options...)
 }
 
-func (fc *fileCache) Open(ctx context.Context, key string) (result *OpenResult, err error) {
+func (fc *fileCache) OpenWriter(ctx context.Context, key string, options ...ItemOptions) (w ItemWriter, err error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -202,43 +256,101 @@ func (fc *fileCache) Open(ctx context.Context, key string) (result *OpenResult,
 		go fc.gc.OnOperation()
 	}()
 
-	result = &OpenResult{}
-
-	fc.keysLocker.Lock(key)
-	defer fc.keysLocker.Unlock(key)
+	opt := ItemOptions{}
 
-	itemPath := fc.getItemPath(key, false, false)
-	metaPath := fc.getItemPath(key, true, false)
+	if len(options) > 0 {
+		opt = options[0]
+	}
 
-	if !util.ItemFilesValid(itemPath, metaPath) {
-		util.DeleteCacheFiles(itemPath, metaPath)
+	unlock, err := fc.env.lock(key)
+	if err != nil {
+		return nil, err
+	}
 
-		return result, nil
+	if opt.Codec == nil {
+		opt.Codec = fc.codec
 	}
 
-	meta, err := readMeta(key, metaPath)
+	registerCodecIfMissing(opt.Codec)
+
+	itemPath := fc.getItemPath(key, false, true)
+
+	itemF, err := createTempCacheFile(fc.env.storage, key, itemPath)
 	if err != nil {
-		util.DeleteCacheFiles(itemPath, metaPath)
+		unlock()
 
-		return result, nil
+		return nil, err
 	}
 
-	if meta.isExpired() {
-		util.DeleteCacheFiles(itemPath, metaPath)
+	iw := &itemWriter{
+		ctx:      ctx,
+		key:      key,
+		meta:     newMeta(key, &opt, fc.ttlDefault),
+		itemPath: itemPath,
+		metaPath: fc.getItemPath(key, true, true),
+		itemF:    itemF,
+		out:      itemF,
+		tags:     fc.tags,
+		crypt:    fc.env.crypt,
+		storage:  fc.env.storage,
+		unlock:   unlock,
+	}
 
-		return result, nil
+	// The checksum algorithm is validated on the instance init.
+	iw.hash, _ = newChecksumHash(fc.checksum)
+	if iw.hash != nil {
+		iw.meta.SumAlgo = string(fc.checksum)
 	}
 
-	result.hit = true
-	result.options = metaToOptions(meta)
+	if err := iw.encode(opt.Codec); err != nil {
+		iw.discard(nil)
+		unlock()
+
+		return nil, err
+	}
+
+	fc.writing.Store(itemPath, struct{}{})
+
+	iw.unlock = func() {
+		fc.writing.Delete(itemPath)
+		unlock()
+	}
+
+	return iw, nil
+}
 
-	result.reader, err = os.Open(itemPath)
+func (fc *fileCache) Open(ctx context.Context, key string) (result *OpenResult, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		go fc.gc.OnOperation()
+	}()
+
+	meta, reader, miss, err := fc.openItem(key)
 	if err != nil {
-		util.DeleteCacheFiles(itemPath, metaPath)
+		return nil, err
+	}
 
-		return nil, fmt.Errorf("failed to open cache file for key %s: %w", key, err)
+	if isStale(miss) {
+		fc.removeStale(key, meta)
 	}
 
+	result = &OpenResult{}
+
+	if reader == nil {
+		result.missReason = miss
+
+		return result, nil
+	}
+
+	result.hit = true
+	result.options = metaToOptions(meta)
+	result.reader = reader
+	result.size = reader.size
+	result.modTime = meta.CreatedAt
+
 	return result, nil
 }
 
@@ -251,9 +363,6 @@ func (fc *fileCache) Read(ctx context.Context, key string) (result *ReadResult,
 		go fc.gc.OnOperation()
 	}()
 
-	itemPath := fc.getItemPath(key, false, false)
-	metaPath := fc.getItemPath(key, true, false)
-
 	openRes, err := fc.Open(ctx, key)
 	if err != nil {
 		return nil, err
@@ -262,13 +371,18 @@ func (fc *fileCache) Read(ctx context.Context, key string) (result *ReadResult,
 	result = &ReadResult{}
 
 	if !openRes.Hit() {
+		result.missReason = openRes.missReason
+
 		return result, nil
 	}
 
+	defer func() {
+		_ = openRes.reader.Close()
+	}()
+
+	// The corrupted item is removed by the reader itself.
 	data, err := util.ReadAll(ctx, openRes.reader)
 	if err != nil {
-		util.DeleteCacheFiles(itemPath, metaPath)
-
 		return nil, fmt.Errorf("failed to read cache data for key %s: %w", key, err)
 	}
 
@@ -279,6 +393,45 @@ func (fc *fileCache) Read(ctx context.Context, key string) (result *ReadResult,
 	return result, nil
 }
 
+func (fc *fileCache) GetOrWrite(ctx context.Context, key string, loader LoaderFn) (result *OpenResult, err error) {
+	result, err = fc.Open(ctx, key)
+	if err != nil || result.Hit() {
+		return result, err
+	}
+
+	for {
+		shared, err := fc.flights.Do(ctx, key, func() error {
+			// The item might be written by the previous flight, which has finished after the miss above.
+			res, err := fc.Open(ctx, key)
+			if err != nil {
+				return err
+			}
+
+			if res.Hit() {
+				return res.reader.Close()
+			}
+
+			return fc.load(ctx, key, loader)
+		})
+
+		// The flight is run with the context of its first caller,
+		// so the other callers retry if it's canceled while their own contexts are alive.
+		if shared && isContextError(err) && ctx.Err() == nil {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fc.Open(ctx, key)
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 func (fc *fileCache) Invalidate(ctx context.Context, key string) error {
 	if err := ctx.Err(); err != nil {
 		return err
@@ -288,15 +441,76 @@ func (fc *fileCache) Invalidate(ctx context.Context, key string) error {
 		go fc.gc.OnOperation()
 	}()
 
-	fc.keysLocker.Lock(key)
-	defer fc.keysLocker.Unlock(key)
+	return fc.remove(key)
+}
 
-	itemPath := fc.getItemPath(key, false, false)
-	metaPath := fc.getItemPath(key, true, false)
+func (fc *fileCache) InvalidateTags(ctx context.Context, tags ...string) (removed int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
-	util.DeleteCacheFiles(itemPath, metaPath)
+	defer func() {
+		go fc.gc.OnOperation()
+	}()
 
-	return nil
+	for _, tag := range tags {
+		keys, err := fc.tags.keys(tag)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return removed, err
+			}
+
+			ok, err := fc.removeTagged(key, tag)
+			if err != nil {
+				return removed, err
+			}
+
+			if ok {
+				removed++
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+func (fc *fileCache) InvalidatePrefix(ctx context.Context, prefix string) (removed int, err error) {
+	return fc.InvalidateMatch(ctx, func(entry ScanEntry) bool {
+		return strings.HasPrefix(entry.Key, prefix)
+	})
+}
+
+func (fc *fileCache) InvalidateMatch(ctx context.Context, match func(entry ScanEntry) bool) (removed int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		go fc.gc.OnOperation()
+	}()
+
+	err = newScanner(fc.dir, scanValid, fc.env).Scan(func(entry ScanEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if !match(entry) {
+			return nil
+		}
+
+		ok, err := fc.removeScanned(entry)
+		if ok {
+			removed++
+		}
+
+		return err
+	})
+
+	return removed, err
 }
 
 func (fc *fileCache) Close() error {
@@ -307,6 +521,347 @@ func (fc *fileCache) Close() error {
 	return nil
 }
 
+// load writes the data returned by the loader.
+func (fc *fileCache) load(ctx context.Context, key string, loader LoaderFn) error {
+	reader, options, err := loader(ctx)
+	if err != nil {
+		return err
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+
+	_, err = fc.Write(ctx, key, reader, options)
+
+	return err
+}
+
+// lookup returns the meta of the valid item stored by the key.
+// If the item is not found, the nil meta and the miss reason are returned.
+// Requires the key to be locked.
+func (fc *fileCache) lookup(key string) (m *meta, miss error) {
+	itemPath := fc.getItemPath(key, false, false)
+	metaPath := fc.getItemPath(key, true, false)
+
+	if !util.ItemFilesValid(fc.env.storage, itemPath, metaPath) {
+		return nil, fc.lookupIncomplete(key, itemPath, metaPath)
+	}
+
+	m, err := fc.env.readMeta(key, metaPath)
+	if errors.Is(err, ErrEncrypted) || errors.Is(err, ErrUnknownEncryptionKey) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptedMeta, err)
+	}
+
+	// The path generator might map different keys to the same path.
+	if m.Key != key {
+		return nil, fmt.Errorf("%w: item of key %s is stored by the path of key %s", ErrKeyMismatch, m.Key, key)
+	}
+
+	if m.isExpired() {
+		return nil, ErrExpired
+	}
+
+	return m, nil
+}
+
+// lookupIncomplete returns the miss reason of the item, which item or meta file is missing.
+// The meta file is published after the item file and removed before it,
+// so the files are re-checked to not consider the item published or removed by another process as corrupted.
+func (fc *fileCache) lookupIncomplete(key string, itemPath string, metaPath string) error {
+	storage := fc.env.storage
+
+	if util.AnyFileExists(storage, metaPath) && !util.AnyFileExists(storage, itemPath) {
+		return fmt.Errorf("%w: item file is missing for key %s", ErrCorrupted, key)
+	}
+
+	if util.AnyFileExists(storage, itemPath) && !fc.isPublishing(metaPath) && !util.AnyFileExists(storage, metaPath) {
+		return fmt.Errorf("%w: meta file is missing for key %s", ErrCorrupted, key)
+	}
+
+	return ErrNotFound
+}
+
+// isPublishing returns true if the meta file is being published by another process,
+// i.e., its temporary file exists. The items are never published by other processes
+// while they are locked with the FileLocking.
+func (fc *fileCache) isPublishing(metaPath string) bool {
+	if fc.env.locksFiles() {
+		return false
+	}
+
+	dir, _ := util.TempCacheFilePattern(metaPath)
+
+	entries, err := fc.env.storage.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		if target, ok := util.TempCacheFileTarget(filepath.Join(dir, entry.Name())); ok && target == metaPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isStale returns true if the item's files should be removed because of the miss reason.
+// The encrypted items are not removed by the instance without their keys.
+func isStale(miss error) bool {
+	return errors.Is(miss, ErrExpired) || errors.Is(miss, ErrCorruptedMeta) || errors.Is(miss, ErrCorrupted)
+}
+
+// openItem opens the reader of the valid item stored by the key under the shared lock.
+// If the item is not found, the nil reader and the miss reason are returned;
+// the meta is returned if it is valid, but the item's data is not.
+func (fc *fileCache) openItem(key string) (m *meta, reader *itemReader, miss error, err error) {
+	unlock, err := fc.env.rlock(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	defer unlock()
+
+	m, f, miss, err := fc.openFile(key)
+	if f == nil || err != nil {
+		return m, nil, miss, err
+	}
+
+	codec, err := getCodec(m.Codec)
+	if err != nil {
+		_ = f.Close()
+
+		return nil, nil, nil, fmt.Errorf("failed to open cache file for key %s: %w", key, err)
+	}
+
+	plain := m.KeyID == "" && codec == nil
+	open := fc.itemOpener(m, codec, f, plain)
+
+	first, _, err := open(0)
+	if err != nil {
+		_ = f.Close()
+
+		// The item's data is not readable by its decryptor or codec, so it's considered as corrupted.
+		return m, nil, fmt.Errorf("%w: %w", ErrCorrupted, err), nil
+	}
+
+	size := int64(-1)
+
+	switch {
+	case m.Size > 0 || m.Sum != "":
+		size = m.Size
+	case plain:
+		if stat, err := f.Stat(); err == nil {
+			size = stat.Size()
+		}
+	}
+
+	// The meta file's modification time is the item's last access time.
+	_ = util.TouchFile(fc.env.storage, fc.getItemPath(key, true, false))
+
+	return m, newItemReader(open, first, size, plain, f), nil, nil
+}
+
+// touchAccess updates the item's access time, which is the meta file's modification time.
+func (fc *fileCache) touchAccess(key string) {
+	unlock, err := fc.env.rlock(key)
+	if err != nil {
+		return
+	}
+
+	defer unlock()
+
+	_ = util.TouchFile(fc.env.storage, fc.getItemPath(key, true, false))
+}
+
+// openFile opens the file of the valid item stored by the key.
+// If the item is not found, the nil file and the miss reason are returned.
+// Requires the key to be locked for reading.
+//
+// If the meta doesn't belong to the item's file, the item's file might be published by another process,
+// which renames the meta file right after it. If the meta file is not being published
+// and the meta is still the published one, the item's file is considered damaged
+// and is verified by the meta's checksum; otherwise, the item is looked up again.
+// If the item is still being published after the retries, it's missed.
+func (fc *fileCache) openFile(key string) (m *meta, f StorageFile, miss error, err error) {
+	metaPath := fc.getItemPath(key, true, false)
+
+	for attempt := 0; attempt <= publishRetries; attempt++ {
+		m, miss = fc.lookup(key)
+		if m == nil {
+			return nil, nil, miss, nil
+		}
+
+		f, err = fc.env.storage.Open(fc.getItemPath(key, false, false))
+		if errors.Is(err, fs.ErrNotExist) {
+			// The item is removed by another process since the lookup.
+			return nil, nil, ErrNotFound, nil
+		}
+
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to open cache file for key %s: %w", key, err)
+		}
+
+		stat, err := f.Stat()
+		if err != nil || m.belongsTo(stat) || fc.env.locksFiles() {
+			return m, f, nil, nil
+		}
+
+		if !fc.isPublishing(metaPath) && fc.isPublished(key, metaPath, m) {
+			return m, f, nil, nil
+		}
+
+		_ = f.Close()
+
+		runtime.Gosched()
+	}
+
+	return nil, nil, fmt.Errorf("%w: item of key %s is being published", ErrNotFound, key), nil
+}
+
+// isPublished returns true if the meta is still stored in the meta file.
+func (fc *fileCache) isPublished(key string, metaPath string, m *meta) bool {
+	current, err := fc.env.readMeta(key, metaPath)
+	if err != nil {
+		return false
+	}
+
+	return current.CreatedAt.Equal(m.CreatedAt) && current.FileModTime == m.FileModTime && current.FileSize == m.FileSize
+}
+
+// itemOpener returns the opener of the item's decoded data readers.
+// The readers read the already opened item's file, so they read the same item's version even if it's rewritten.
+// The readers opened at the data start verify the data, removing the item if it's corrupted.
+func (fc *fileCache) itemOpener(m *meta, codec Codec, f StorageFile, plain bool) itemOpener {
+	onCorrupted := func() {
+		fc.removeStale(m.Key, m)
+	}
+
+	return func(off int64) (io.ReadCloser, int64, error) {
+		sr := io.NewSectionReader(f, 0, math.MaxInt64)
+
+		if !plain {
+			off = 0
+		}
+
+		if _, err := sr.Seek(off, io.SeekStart); err != nil {
+			return nil, 0, err
+		}
+
+		r, err := decodeItem(m, codec, fc.env.crypt, io.NopCloser(sr))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return newVerifyingReader(m, r, off == 0, onCorrupted), off, nil
+	}
+}
+
+// remove removes the item files under the exclusive lock.
+// Returns the ErrKeyMismatch error if the item's path is taken by another key.
+func (fc *fileCache) remove(key string) error {
+	unlock, err := fc.env.lock(key)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	// The item of another key stored by the same path is not removed.
+	if _, miss := fc.lookup(key); errors.Is(miss, ErrKeyMismatch) {
+		return miss
+	}
+
+	fc.deleteItem(key)
+
+	return nil
+}
+
+// removeTagged removes the item if it still has the tag under the exclusive lock.
+// The stale tag's entries are removed anyway.
+func (fc *fileCache) removeTagged(key string, tag string) (ok bool, err error) {
+	unlock, err := fc.env.lock(key)
+	if err != nil {
+		return false, err
+	}
+
+	defer unlock()
+
+	tags := []string{tag}
+
+	m, miss := fc.lookup(key)
+	if m == nil || !hasAnyTag(m.Tags, tags) {
+		fc.tags.remove(key, tags)
+
+		if isStale(miss) {
+			fc.deleteItem(key)
+		}
+
+		return false, nil
+	}
+
+	fc.deleteItem(key)
+
+	return true, nil
+}
+
+// removeScanned removes the scanned item under the exclusive lock,
+// if it was not rewritten since the scan.
+func (fc *fileCache) removeScanned(entry ScanEntry) (ok bool, err error) {
+	unlock, err := fc.env.lock(entry.Key)
+	if err != nil {
+		return false, err
+	}
+
+	defer unlock()
+
+	m, _ := fc.lookup(entry.Key)
+	if m == nil || m.Key != entry.Key || !m.CreatedAt.Equal(entry.CreatedAt) {
+		return false, nil
+	}
+
+	fc.deleteItem(entry.Key)
+
+	return true, nil
+}
+
+// removeStale removes the item files under the exclusive lock if they are still stale
+// or if the item is still of the invalid version.
+func (fc *fileCache) removeStale(key string, invalid *meta) {
+	unlock, err := fc.env.lock(key)
+	if err != nil {
+		return
+	}
+
+	defer unlock()
+
+	m, miss := fc.lookup(key)
+	if isStale(miss) || m != nil && invalid != nil && m.CreatedAt.Equal(invalid.CreatedAt) {
+		fc.deleteItem(key)
+	}
+}
+
+// deleteItem removes the item files and the item's tags index entries.
+// Requires the key to be locked.
+func (fc *fileCache) deleteItem(key string) {
+	itemPath := fc.getItemPath(key, false, false)
+	metaPath := fc.getItemPath(key, true, false)
+
+	if m, err := fc.env.readMeta(key, metaPath); err == nil {
+		fc.tags.remove(key, m.Tags)
+	}
+
+	// The meta file is removed first, so the item is never paired with another item's meta.
+	util.DeleteCacheFiles(fc.env.storage, metaPath, itemPath)
+}
+
 func (fc *fileCache) getItemPath(key string, forMeta bool, createDirs bool) string {
-	return util.GetItemPath(fc.GetPath(), fc.pathGenerator, key, forMeta, createDirs)
+	return util.GetItemPath(fc.env.storage, fc.GetPath(), fc.pathGenerator, key, forMeta, createDirs)
 }
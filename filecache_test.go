@@ -2,9 +2,17 @@ package filecache_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/kukymbr/filecache/v2"
@@ -186,3 +194,447 @@ func TestFileCache_WhenContextCanceled_ExpectError(t *testing.T) {
 		assert.ErrorIs(t, err, context.Canceled)
 	}
 }
+
+func TestFileCache_Write_WhenOverwritten_ExpectNewValue(t *testing.T) {
+	target := getTarget(t, "writeread")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value1"))
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value2"), filecache.ItemOptions{Name: "Name2"})
+	require.NoError(t, err)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, "value2", string(res.Data()))
+	assert.Equal(t, "Name2", res.Options().Name)
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"test", "test--meta"}, names)
+}
+
+func TestFileCache_Write_WhenReaderFails_ExpectPreviousItemKept(t *testing.T) {
+	target := getTarget(t, "writeread")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value1"))
+	require.NoError(t, err)
+
+	failing := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("test error")))
+
+	n, err := fc.Write(context.Background(), "test", failing)
+
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), n)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, "value1", string(res.Data()))
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestFileCache_WhenConcurrent_ExpectNoRaces(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "concurrent"))
+	require.NoError(t, err)
+
+	const (
+		keysCount  = 8
+		goroutines = 32
+		iterations = 20
+	)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			ctx := context.Background()
+
+			for i := 0; i < iterations; i++ {
+				key := fmt.Sprintf("key%d", (g+i)%keysCount)
+				value := "value-" + key
+
+				switch (g + i) % 4 {
+				case 0, 1:
+					_, err := fc.WriteData(ctx, key, []byte(value))
+					assert.NoError(t, err)
+				case 2:
+					res, err := fc.Read(ctx, key)
+					if assert.NoError(t, err) && res.Hit() {
+						assert.Equal(t, value, string(res.Data()))
+					}
+				default:
+					assert.NoError(t, fc.Invalidate(ctx, key))
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	assert.NoError(t, fc.Close())
+}
+
+func TestFileCache_WhenDirShared_ExpectCompleteItems(t *testing.T) {
+	target := getTarget(t, "concurrent")
+
+	writer, err := filecache.New(target)
+	require.NoError(t, err)
+
+	reader, err := filecache.New(target)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	last := ""
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 500; i++ {
+			last = strings.Repeat(strconv.Itoa(i), i%7+1)
+
+			_, err := writer.WriteData(ctx, "k", []byte(last), filecache.ItemOptions{Name: last})
+			assert.NoError(t, err)
+		}
+	}()
+
+	for i := 0; i < 2000; i++ {
+		res, err := reader.Read(ctx, "k")
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		if res.Hit() {
+			assert.Equal(t, res.Options().Name, string(res.Data()))
+		} else {
+			assert.ErrorIs(t, res.MissReason(), filecache.ErrNotFound)
+		}
+	}
+
+	<-done
+
+	res, err := reader.Read(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	assert.Equal(t, last, string(res.Data()))
+}
+
+func TestFileCache_WhenWriting_ExpectReadersWait(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "concurrent"))
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value1"))
+	require.NoError(t, err)
+
+	pr, pw := io.Pipe()
+	writeDone := make(chan struct{})
+
+	go func() {
+		defer close(writeDone)
+
+		_, err := fc.Write(context.Background(), "test", pr)
+		assert.NoError(t, err)
+	}()
+
+	// Writer is holding the key now.
+	_, _ = pw.Write([]byte("val"))
+
+	readDone := make(chan string)
+
+	go func() {
+		res, err := fc.Read(context.Background(), "test")
+		assert.NoError(t, err)
+
+		readDone <- string(res.Data())
+	}()
+
+	select {
+	case <-readDone:
+		t.Fatal("reader didn't wait for the writer")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	_, _ = pw.Write([]byte("ue2"))
+	_ = pw.Close()
+
+	<-writeDone
+
+	assert.Equal(t, "value2", <-readDone)
+}
+
+func TestFileCache_GetOrWrite_WhenConcurrentMisses_ExpectSingleLoad(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "getorwrite"))
+	require.NoError(t, err)
+
+	const callers = 16
+
+	var (
+		loads int32
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+
+	loader := func(ctx context.Context) (io.Reader, filecache.ItemOptions, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(50 * time.Millisecond)
+
+		return strings.NewReader("value"), filecache.ItemOptions{Name: "Name"}, nil
+	}
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			res, err := fc.GetOrWrite(context.Background(), "test", loader)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			defer func() {
+				_ = res.Reader().Close()
+			}()
+
+			data, err := io.ReadAll(res.Reader())
+
+			assert.NoError(t, err)
+			assert.True(t, res.Hit())
+			assert.Equal(t, "value", string(data))
+			assert.Equal(t, "Name", res.Options().Name)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// The item is cached now, so the loader is not called anymore.
+	res, err := fc.GetOrWrite(context.Background(), "test", loader)
+	require.NoError(t, err)
+
+	_ = res.Reader().Close()
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+}
+
+func TestFileCache_GetOrWrite_WhenLoaderFails_ExpectError(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "getorwrite"))
+	require.NoError(t, err)
+
+	testErr := errors.New("test error")
+
+	res, err := fc.GetOrWrite(context.Background(), "test", func(ctx context.Context) (io.Reader, filecache.ItemOptions, error) {
+		return nil, filecache.ItemOptions{}, testErr
+	})
+
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, testErr)
+
+	readRes, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.False(t, readRes.Hit())
+}
+
+func TestFileCache_GetOrWrite_WhenLeaderCanceled_ExpectWaiterLoaded(t *testing.T) {
+	for name, fc := range newMemoryAndFileCaches(t, filecache.InstanceOptions{}) {
+		var loads atomic.Int32
+
+		started := make(chan struct{})
+
+		loader := func(ctx context.Context) (io.Reader, filecache.ItemOptions, error) {
+			if loads.Add(1) == 1 {
+				close(started)
+				<-ctx.Done()
+
+				return nil, filecache.ItemOptions{}, ctx.Err()
+			}
+
+			return strings.NewReader("value"), filecache.ItemOptions{}, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		leaderDone := make(chan error)
+
+		go func() {
+			_, err := fc.GetOrWrite(ctx, "test", loader)
+			leaderDone <- err
+		}()
+
+		<-started
+
+		waiterDone := make(chan *filecache.OpenResult)
+
+		go func() {
+			res, err := fc.GetOrWrite(context.Background(), "test", loader)
+			assert.NoError(t, err, name)
+			waiterDone <- res
+		}()
+
+		// The waiter joins the leader's flight.
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		assert.ErrorIs(t, <-leaderDone, context.Canceled, name)
+
+		res := <-waiterDone
+		require.NotNil(t, res, name)
+		require.True(t, res.Hit(), name)
+
+		data, err := io.ReadAll(res.Reader())
+		require.NoError(t, err, name)
+		require.NoError(t, res.Reader().Close(), name)
+
+		assert.Equal(t, "value", string(data), name)
+		assert.Equal(t, int32(2), loads.Load(), name)
+	}
+}
+
+func TestFileCache_GetOrWrite_WhenWaiterContextDone_ExpectContextError(t *testing.T) {
+	for name, fc := range newMemoryAndFileCaches(t, filecache.InstanceOptions{}) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		leaderDone := make(chan error)
+
+		go func() {
+			_, err := fc.GetOrWrite(context.Background(), "test", func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+				close(started)
+				<-release
+
+				return strings.NewReader("value"), filecache.ItemOptions{}, nil
+			})
+			leaderDone <- err
+		}()
+
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		begin := time.Now()
+		res, err := fc.GetOrWrite(ctx, "test", func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+			return strings.NewReader("waiter"), filecache.ItemOptions{}, nil
+		})
+
+		cancel()
+
+		assert.Nil(t, res, name)
+		assert.ErrorIs(t, err, context.DeadlineExceeded, name)
+		assert.Less(t, time.Since(begin), time.Second, name)
+
+		close(release)
+		assert.NoError(t, <-leaderDone, name)
+	}
+}
+
+func TestFileCache_WhenMiss_ExpectMissReason(t *testing.T) {
+	target := getTarget(t, "writeread")
+	ctx := context.Background()
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	for _, key := range []string{"hit", "meta", "item"} {
+		_, err := fc.WriteData(ctx, key, []byte("value"))
+		require.NoError(t, err)
+	}
+
+	_, err = fc.WriteData(ctx, "expired", []byte("value"), filecache.ItemOptions{TTL: time.Nanosecond})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(target, "meta--meta"), []byte("{invalid"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(target, "item")))
+
+	tests := map[string]error{
+		"hit":     nil,
+		"unknown": filecache.ErrNotFound,
+		"expired": filecache.ErrExpired,
+		"meta":    filecache.ErrCorruptedMeta,
+		"item":    filecache.ErrCorrupted,
+	}
+
+	for key, expected := range tests {
+		openRes, err := fc.Open(ctx, key)
+		require.NoError(t, err)
+
+		readRes, err := fc.Read(ctx, key)
+		require.NoError(t, err)
+
+		if expected == nil {
+			assert.True(t, openRes.Hit())
+			assert.NoError(t, openRes.MissReason())
+			assert.NoError(t, readRes.MissReason())
+			assert.NoError(t, openRes.Reader().Close())
+
+			continue
+		}
+
+		assert.False(t, openRes.Hit(), key)
+		assert.ErrorIs(t, openRes.MissReason(), expected, key)
+
+		// The stale item is removed by the Open call, so it's not found anymore.
+		assert.False(t, readRes.Hit(), key)
+		assert.ErrorIs(t, readRes.MissReason(), filecache.ErrNotFound, key)
+	}
+}
+
+func TestFileCache_WhenPathCollision_ExpectKeyMismatch(t *testing.T) {
+	target := getTarget(t, "writeread")
+	ctx := context.Background()
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	require.Equal(t, filecache.FilteredKeyPath("a/b"), filecache.FilteredKeyPath("ab"))
+
+	_, err = fc.WriteData(ctx, "a/b", []byte("value"))
+	require.NoError(t, err)
+
+	openRes, err := fc.Open(ctx, "ab")
+	require.NoError(t, err)
+	assert.False(t, openRes.Hit())
+	assert.ErrorIs(t, openRes.MissReason(), filecache.ErrKeyMismatch)
+
+	readRes, err := fc.Read(ctx, "ab")
+	require.NoError(t, err)
+	assert.False(t, readRes.Hit())
+	assert.ErrorIs(t, readRes.MissReason(), filecache.ErrKeyMismatch)
+
+	err = fc.Invalidate(ctx, "ab")
+	assert.ErrorIs(t, err, filecache.ErrKeyMismatch)
+
+	readRes, err = fc.Read(ctx, "a/b")
+	require.NoError(t, err)
+	assert.True(t, readRes.Hit())
+	assert.Equal(t, "value", string(readRes.Data()))
+
+	assert.NoError(t, fc.Invalidate(ctx, "a/b"))
+	assert.NoError(t, fc.Invalidate(ctx, "ab"))
+}
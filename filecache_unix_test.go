@@ -0,0 +1,111 @@
+//go:build unix
+
+package filecache_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache_WhenFileLocking_ExpectInstancesSynchronized(t *testing.T) {
+	target := getTarget(t, "filelocking")
+	options := filecache.InstanceOptions{FileLocking: true}
+
+	// Instances don't share the in-process locks, like the instances in the different processes.
+	fc1, err := filecache.New(target, options)
+	require.NoError(t, err)
+
+	fc2, err := filecache.New(target, options)
+	require.NoError(t, err)
+
+	assert.DirExists(t, target+"/.filecache-locks")
+
+	pr, pw := io.Pipe()
+	writeDone := make(chan struct{})
+
+	go func() {
+		defer close(writeDone)
+
+		_, err := fc1.Write(context.Background(), "test", pr)
+		assert.NoError(t, err)
+	}()
+
+	// The first instance is holding the key now.
+	_, _ = pw.Write([]byte("val"))
+
+	invalidateDone := make(chan struct{})
+
+	go func() {
+		defer close(invalidateDone)
+
+		assert.NoError(t, fc2.Invalidate(context.Background(), "test"))
+	}()
+
+	select {
+	case <-invalidateDone:
+		t.Fatal("second instance didn't wait for the first one")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	_, _ = pw.Write([]byte("ue"))
+	_ = pw.Close()
+
+	<-writeDone
+	<-invalidateDone
+
+	res, err := fc2.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.False(t, res.Hit())
+}
+
+func TestFileCache_WhenFileLockingWriters_ExpectOtherKeysNotBlocked(t *testing.T) {
+	target := getTarget(t, "filelocking")
+	options := filecache.InstanceOptions{FileLocking: true}
+	ctx := context.Background()
+
+	fc1, err := filecache.New(target, options)
+	require.NoError(t, err)
+
+	fc2, err := filecache.New(target, options)
+	require.NoError(t, err)
+
+	// The keys would share the lock files with the written keys, if the keys were hashed to 256 lock files.
+	for _, key := range []string{"d146", "b72"} {
+		_, err := fc1.WriteData(ctx, key, []byte("value"))
+		require.NoError(t, err)
+	}
+
+	w1, err := fc1.OpenWriter(ctx, "a")
+	require.NoError(t, err)
+
+	w2, err := fc2.OpenWriter(ctx, "e")
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for fc, key := range map[filecache.FileCache]string{fc1: "d146", fc2: "b72"} {
+			res, err := fc.Read(ctx, key)
+			assert.NoError(t, err, key)
+			assert.True(t, res.Hit(), key)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reads are blocked by the writers of the other keys")
+	}
+
+	require.NoError(t, w1.Abort())
+	require.NoError(t, w2.Abort())
+}
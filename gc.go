@@ -1,6 +1,11 @@
 package filecache
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
 
 // GarbageCollector is a tool to remove expired cache items.
 type GarbageCollector interface {
@@ -46,3 +51,74 @@ func NewIntervalGarbageCollector(dir string, interval time.Duration) GarbageColl
 		interval: interval,
 	}
 }
+
+// NewLRUGarbageCollector returns the GarbageCollector removing the expired items
+// and evicting the least recently used items when the cache exceeds its budget.
+//
+// Function arguments:
+//   - dir: the directory with the FileCache's instance files;
+//   - maxSize: the maximum total size of the items' data files in bytes, zero means no limit;
+//   - maxItems: the maximum number of the items, zero means no limit.
+//
+// The GC runs on the instance init and after the operations, but not more often than once a second,
+// so the cache may exceed its budget for a while.
+// The item's last access time is the modification time of its meta file, updated on every Open and Read.
+func NewLRUGarbageCollector(dir string, maxSize int64, maxItems int) GarbageCollector {
+	return &gcLRU{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxItems: maxItems,
+	}
+}
+
+// newInstanceGC returns the GarbageCollector defined by the instance options.
+func newInstanceGC(dir string, opt InstanceOptions) (GarbageCollector, error) {
+	hasLimits := opt.MaxSize > 0 || opt.MaxItems > 0
+
+	switch {
+	case hasLimits && opt.GC != nil:
+		return nil, fmt.Errorf("MaxSize and MaxItems options can't be combined with the GC option")
+	case opt.GC != nil:
+		return opt.GC, nil
+	case hasLimits:
+		return NewLRUGarbageCollector(dir, opt.MaxSize, opt.MaxItems), nil
+	case opt.GCDivisor != 0:
+		return NewProbabilityGarbageCollector(dir, 1, opt.GCDivisor), nil
+	default:
+		return NewProbabilityGarbageCollector(dir, 1, 100), nil
+	}
+}
+
+// removeExpired removes the expired items from the dir.
+// The items are removed under the exclusive locks of the environment.
+func removeExpired(dir string, env *itemsEnv) {
+	scanner := newScanner(dir, scanExpired, env)
+
+	_ = scanner.Scan(func(entry ScanEntry) error {
+		unlock, err := env.lock(entry.Key)
+		if err != nil {
+			//nolint:nilerr
+			return nil
+		}
+
+		defer unlock()
+
+		// The item might be rewritten while the lock has been awaited.
+		if m, err := env.readMeta(entry.Key, entry.metaPath); err == nil && !m.isExpired() {
+			return nil
+		}
+
+		deleteEntry(dir, env, entry)
+
+		return nil
+	})
+}
+
+// deleteEntry removes the scanned item's files and its tags index entries.
+func deleteEntry(dir string, env *itemsEnv, entry ScanEntry) {
+	if entry.Options != nil {
+		newTagsIndex(dir, env).remove(entry.Key, entry.Options.Tags)
+	}
+
+	util.DeleteCacheFiles(env.getStorage(), entry.metaPath, entry.itemPath)
+}
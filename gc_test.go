@@ -30,14 +30,14 @@ func prepareGCTestFiles(t *testing.T) {
 	f1, err := os.Create("./testdata/gc/test1.cache--meta")
 	require.NoError(t, err)
 
-	err = saveMeta(context.Background(), m1, f1)
+	err = saveMeta(context.Background(), m1, f1, nil)
 	require.NoError(t, err)
 
 	m2 := newMeta("test2", &ItemOptions{TTL: time.Hour}, time.Hour)
 	f2, err := os.Create("./testdata/gc/test2.cache--meta")
 	require.NoError(t, err)
 
-	err = saveMeta(context.Background(), m2, f2)
+	err = saveMeta(context.Background(), m2, f2, nil)
 	require.NoError(t, err)
 
 	// To invalidate test1 item.
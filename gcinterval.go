@@ -3,13 +3,12 @@ package filecache
 import (
 	"context"
 	"time"
-
-	"github.com/kukymbr/filecache/v2/internal/util"
 )
 
 type gcInterval struct {
 	dir      string
 	interval time.Duration
+	env      *itemsEnv
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -34,6 +33,10 @@ func (g *gcInterval) OnInstanceInit() {
 
 func (g *gcInterval) OnOperation() {}
 
+func (g *gcInterval) bindEnv(env *itemsEnv) {
+	g.env = env
+}
+
 func (g *gcInterval) Close() error {
 	g.ticker.Stop()
 	g.cancel()
@@ -42,11 +45,5 @@ func (g *gcInterval) Close() error {
 }
 
 func (g *gcInterval) run() {
-	scanner := newExpiredScanner(g.dir)
-
-	_ = scanner.Scan(func(entry ScanEntry) error {
-		util.DeleteCacheFiles(entry.itemPath, entry.metaPath)
-
-		return nil
-	})
+	removeExpired(g.dir, g.env)
 }
@@ -0,0 +1,111 @@
+package filecache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// lruMinInterval is a minimal interval between the LRU GC runs triggered by the operations.
+const lruMinInterval = time.Second
+
+type gcLRU struct {
+	dir      string
+	maxSize  int64
+	maxItems int
+	env      *itemsEnv
+
+	mu      sync.Mutex
+	lastRun time.Time
+}
+
+func (g *gcLRU) OnInstanceInit() {
+	g.tryRun(true)
+}
+
+func (g *gcLRU) OnOperation() {
+	g.tryRun(false)
+}
+
+func (g *gcLRU) bindEnv(env *itemsEnv) {
+	g.env = env
+}
+
+func (g *gcLRU) Close() error {
+	return nil
+}
+
+// tryRun runs the GC if it is not running already and, unless forced, if the previous run is not too recent.
+func (g *gcLRU) tryRun(force bool) {
+	if !g.mu.TryLock() {
+		return
+	}
+
+	defer g.mu.Unlock()
+
+	if !force && time.Since(g.lastRun) < lruMinInterval {
+		return
+	}
+
+	g.run()
+
+	g.lastRun = time.Now()
+}
+
+func (g *gcLRU) run() {
+	removeExpired(g.dir, g.env)
+
+	entries := make([]ScanEntry, 0)
+
+	var totalSize int64
+
+	_ = newScanner(g.dir, scanValid, g.env).Scan(func(entry ScanEntry) error {
+		entries = append(entries, entry)
+		totalSize += entry.Size
+
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AccessedAt.Before(entries[j].AccessedAt)
+	})
+
+	count := len(entries)
+
+	for _, entry := range entries {
+		if !g.exceeds(totalSize, count) {
+			return
+		}
+
+		// The entry rewritten since the scan is kept and still counted.
+		if g.evict(entry) {
+			totalSize -= entry.Size
+			count--
+		}
+	}
+}
+
+func (g *gcLRU) exceeds(totalSize int64, count int) bool {
+	return g.maxSize > 0 && totalSize > g.maxSize || g.maxItems > 0 && count > g.maxItems
+}
+
+// evict removes the scanned entry, if it's not rewritten or removed since the scan.
+// Returns true if the entry is removed.
+func (g *gcLRU) evict(entry ScanEntry) bool {
+	unlock, err := g.env.lock(entry.Key)
+	if err != nil {
+		return false
+	}
+
+	defer unlock()
+
+	// The item might be rewritten or removed since the scan.
+	m, err := g.env.readMeta(entry.Key, entry.metaPath)
+	if err != nil || m.Key != entry.Key || !m.CreatedAt.Equal(entry.CreatedAt) {
+		return false
+	}
+
+	deleteEntry(g.dir, g.env, entry)
+
+	return true
+}
@@ -0,0 +1,136 @@
+package filecache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareLRUTestCache(t *testing.T, count int) FileCache {
+	t.Cleanup(func() {
+		_ = os.RemoveAll("./testdata/gclru")
+	})
+
+	_ = os.RemoveAll("./testdata/gclru")
+
+	fc, err := New("./testdata/gclru", InstanceOptions{
+		PathGenerator: FilteredKeyPath,
+		GC:            NewNopGarbageCollector(),
+	})
+	require.NoError(t, err)
+
+	accessedAt := time.Now().Add(-time.Hour)
+
+	for i := 0; i < count; i++ {
+		key := fmt.Sprintf("test%d", i)
+
+		_, err := fc.WriteData(context.Background(), key, []byte("0123456789"))
+		require.NoError(t, err)
+
+		// Items are accessed in the order of their keys.
+		accessedAt = accessedAt.Add(time.Minute)
+		err = os.Chtimes("./testdata/gclru/"+key+"--meta", accessedAt, accessedAt)
+		require.NoError(t, err)
+	}
+
+	return fc
+}
+
+func TestLRUGarbageCollector_WhenMaxItemsExceeded_ExpectLeastRecentlyUsedEvicted(t *testing.T) {
+	fc := prepareLRUTestCache(t, 5)
+
+	// Access the oldest item, so it becomes the most recently used one.
+	res, err := fc.Read(context.Background(), "test0")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+
+	gc := NewLRUGarbageCollector("./testdata/gclru", 0, 3).(*gcLRU)
+	gc.run()
+
+	assert.FileExists(t, "./testdata/gclru/test0")
+	assert.NoFileExists(t, "./testdata/gclru/test1")
+	assert.NoFileExists(t, "./testdata/gclru/test1--meta")
+	assert.NoFileExists(t, "./testdata/gclru/test2")
+	assert.FileExists(t, "./testdata/gclru/test3")
+	assert.FileExists(t, "./testdata/gclru/test4")
+}
+
+func TestLRUGarbageCollector_WhenMaxSizeExceeded_ExpectLeastRecentlyUsedEvicted(t *testing.T) {
+	prepareLRUTestCache(t, 5)
+
+	gc := NewLRUGarbageCollector("./testdata/gclru", 25, 0).(*gcLRU)
+	gc.OnInstanceInit()
+
+	assert.NoFileExists(t, "./testdata/gclru/test0")
+	assert.NoFileExists(t, "./testdata/gclru/test1")
+	assert.NoFileExists(t, "./testdata/gclru/test2")
+	assert.FileExists(t, "./testdata/gclru/test3")
+	assert.FileExists(t, "./testdata/gclru/test4")
+
+	// Next run by the operation is skipped as too recent.
+	gc.maxItems = 1
+	gc.OnOperation()
+
+	assert.FileExists(t, "./testdata/gclru/test3")
+
+	assert.NoError(t, gc.Close())
+}
+
+func TestLRUGarbageCollector_WhenWithinBudget_ExpectNothingEvicted(t *testing.T) {
+	prepareLRUTestCache(t, 3)
+
+	gc := NewLRUGarbageCollector("./testdata/gclru", 100, 3).(*gcLRU)
+	gc.run()
+
+	for i := 0; i < 3; i++ {
+		assert.FileExists(t, fmt.Sprintf("./testdata/gclru/test%d", i))
+	}
+}
+
+func TestLRUGarbageCollector_WhenRewrittenSinceScan_ExpectKept(t *testing.T) {
+	fc := prepareLRUTestCache(t, 1)
+
+	entries := make([]ScanEntry, 0)
+
+	err := NewScanner("./testdata/gclru").Scan(func(entry ScanEntry) error {
+		entries = append(entries, entry)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = fc.WriteData(context.Background(), "test0", []byte("rewritten"))
+	require.NoError(t, err)
+
+	gc := NewLRUGarbageCollector("./testdata/gclru", 0, 0).(*gcLRU)
+	assert.False(t, gc.evict(entries[0]))
+
+	res, err := fc.Read(context.Background(), "test0")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	assert.Equal(t, "rewritten", string(res.Data()))
+
+	// The entry of the current item is evicted.
+	err = NewScanner("./testdata/gclru").Scan(func(entry ScanEntry) error {
+		assert.True(t, gc.evict(entry))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, "./testdata/gclru/test0")
+	assert.NoFileExists(t, "./testdata/gclru/test0--meta")
+}
+
+func TestNew_WhenLimitsWithGC_ExpectError(t *testing.T) {
+	fc, err := New("", InstanceOptions{MaxItems: 1, GC: NewNopGarbageCollector()})
+
+	assert.Nil(t, fc)
+	assert.Error(t, err)
+}
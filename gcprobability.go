@@ -1,13 +1,10 @@
 package filecache
 
-import (
-	"math/rand"
-
-	"github.com/kukymbr/filecache/v2/internal/util"
-)
+import "math/rand"
 
 type gcProbability struct {
 	dir string
+	env *itemsEnv
 
 	onInitDivisor uint
 	onOpDivisor   uint
@@ -21,6 +18,10 @@ func (g *gcProbability) OnOperation() {
 	g.run(g.onOpDivisor)
 }
 
+func (g *gcProbability) bindEnv(env *itemsEnv) {
+	g.env = env
+}
+
 func (g *gcProbability) Close() error {
 	return nil
 }
@@ -30,13 +31,7 @@ func (g *gcProbability) run(divisor uint) {
 		return
 	}
 
-	scanner := newExpiredScanner(g.dir)
-
-	_ = scanner.Scan(func(entry ScanEntry) error {
-		util.DeleteCacheFiles(entry.itemPath, entry.metaPath)
-
-		return nil
-	})
+	removeExpired(g.dir, g.env)
 }
 
 func (g *gcProbability) decideToRun(divisor uint) bool {
@@ -0,0 +1,150 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl is a parsed Cache-Control header, directives' names are lowercased.
+type cacheControl map[string]string
+
+// parseCacheControl parses the Cache-Control header values.
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+
+	for _, value := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+
+			name, arg, _ := strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+
+	return ok
+}
+
+// seconds returns the directive's delta-seconds value.
+func (cc cacheControl) seconds(name string) (d time.Duration, ok bool) {
+	arg, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return time.Duration(n) * time.Second, true
+}
+
+// freshness returns the freshness lifetime of the response defined by its headers
+// for a shared or a private cache. The ok flag is false if the lifetime is not defined explicitly.
+func freshness(cc cacheControl, h http.Header, now time.Time, shared bool) (lifetime time.Duration, ok bool) {
+	if d, ok := cc.seconds("s-maxage"); ok && shared {
+		return d, true
+	}
+
+	if d, ok := cc.seconds("max-age"); ok {
+		return d, true
+	}
+
+	if expires := h.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			// The invalid Expires value means the response is already expired.
+			return 0, true
+		}
+
+		date := now
+
+		if d, err := http.ParseTime(h.Get("Date")); err == nil {
+			date = d
+		}
+
+		return exp.Sub(date), true
+	}
+
+	return 0, false
+}
+
+// varyNames returns the canonical names of the request headers listed in the Vary header.
+// The ok flag is false if the response varies on anything ("*").
+func varyNames(h http.Header) (names []string, ok bool) {
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+
+	return names, true
+}
+
+// varyKey returns the key of the response's variant selected by the request's headers.
+func varyKey(key string, names []string, r *http.Request) string {
+	var sb strings.Builder
+
+	sb.WriteString(key)
+
+	for _, name := range names {
+		sb.WriteString("\nvary:")
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+
+	return sb.String()
+}
+
+// hopByHopHeaders are the headers meaningful only for a single connection, not stored in the cache.
+// The Age header is not stored as well, it's calculated when the response is served from the cache.
+var hopByHopHeaders = []string{
+	"Age",
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// storedHeader returns the copy of the header to store in the cache.
+func storedHeader(h http.Header) http.Header {
+	stored := h.Clone()
+
+	for _, name := range hopByHopHeaders {
+		stored.Del(name)
+	}
+
+	for _, name := range strings.Split(strings.Join(h.Values("Connection"), ","), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			stored.Del(name)
+		}
+	}
+
+	return stored
+}
@@ -0,0 +1,159 @@
+package httpcache
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// The names of the cache item's fields, describing the stored response.
+const (
+	fieldStatus   = "http.status"
+	fieldHeader   = "http.header"
+	fieldStoredAt = "http.stored"
+	fieldLifetime = "http.lifetime"
+	fieldVary     = "http.vary"
+)
+
+// entry is a stored response's description.
+type entry struct {
+	// status is a response status code.
+	status int
+
+	// header is a response header.
+	header http.Header
+
+	// storedAt is a time when the response has been stored, corrected by the response's initial age.
+	storedAt time.Time
+
+	// lifetime is a freshness lifetime of the response.
+	lifetime time.Duration
+
+	// vary are the names of the request headers selecting the response's variant.
+	// If not empty, the entry is a marker of the varying response, stored without the body.
+	vary []string
+}
+
+func (e *entry) fields() filecache.Values {
+	values := filecache.NewValues(fieldStoredAt, e.storedAt.UnixMilli())
+
+	if len(e.vary) > 0 {
+		values[fieldVary] = e.vary
+
+		return values
+	}
+
+	values[fieldStatus] = e.status
+	values[fieldHeader] = map[string][]string(e.header)
+	values[fieldLifetime] = e.lifetime.Milliseconds()
+
+	return values
+}
+
+// age returns the time passed since the entry has been stored.
+func (e *entry) age(now time.Time) time.Duration {
+	if age := now.Sub(e.storedAt); age > 0 {
+		return age
+	}
+
+	return 0
+}
+
+// isFresh returns true if the entry's age doesn't exceed its freshness lifetime.
+func (e *entry) isFresh(now time.Time) bool {
+	return e.age(now) < e.lifetime
+}
+
+// parseEntry reads the entry from the cache item's fields.
+// The fields are decoded from JSON when read from the file,
+// so the numbers are float64 and the slices & maps hold the any values.
+func parseEntry(values filecache.Values) (e *entry, ok bool) {
+	if values == nil {
+		return nil, false
+	}
+
+	storedAt, ok := fieldInt(values[fieldStoredAt])
+	if !ok {
+		return nil, false
+	}
+
+	e = &entry{storedAt: time.UnixMilli(storedAt)}
+
+	if vary, ok := values[fieldVary]; ok {
+		e.vary = fieldStrings(vary)
+
+		return e, len(e.vary) > 0
+	}
+
+	status, ok := fieldInt(values[fieldStatus])
+	if !ok {
+		return nil, false
+	}
+
+	lifetime, _ := fieldInt(values[fieldLifetime])
+
+	e.status = int(status)
+	e.header = fieldToHeader(values[fieldHeader])
+	e.lifetime = time.Duration(lifetime) * time.Millisecond
+
+	return e, true
+}
+
+func fieldInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func fieldStrings(v any) []string {
+	switch s := v.(type) {
+	case []string:
+		return s
+	case []any:
+		strs := make([]string, 0, len(s))
+
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+
+		return strs
+	default:
+		return nil
+	}
+}
+
+func fieldToHeader(v any) http.Header {
+	h := http.Header{}
+
+	switch m := v.(type) {
+	case http.Header:
+		for name, values := range m {
+			h[name] = values
+		}
+	case map[string][]string:
+		for name, values := range m {
+			h[name] = values
+		}
+	case map[string]any:
+		for name, values := range m {
+			h[name] = fieldStrings(values)
+		}
+	}
+
+	return h
+}
@@ -0,0 +1,66 @@
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// ItemHandler returns the http.Handler serving the cache items' data by the keys returned by the keyFunc.
+// If the keyFunc is nil, the request's URL path is used as a key. See the ServeItem for details.
+func ItemHandler(fc filecache.FileCache, keyFunc func(r *http.Request) string) http.Handler {
+	if keyFunc == nil {
+		keyFunc = func(r *http.Request) string {
+			return r.URL.Path
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeItem(w, r, fc, keyFunc(r))
+	})
+}
+
+// ServeItem replies to the request with the data of the cache item using the http.ServeContent,
+// so the range requests and the conditional requests are handled.
+//
+// The response has the strong ETag derived from the item's key, write time and size
+// and the Last-Modified header of the item's write time.
+// The Content-Type is detected by the extension of the item's name or by the data,
+// unless the header is already set.
+// If the item is not found, the 404 Not Found status is replied.
+func ServeItem(w http.ResponseWriter, r *http.Request, fc filecache.FileCache, key string) {
+	res, err := fc.Open(r.Context(), key)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	if !res.Hit() {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	defer func() {
+		_ = res.Reader().Close()
+	}()
+
+	if w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", itemETag(key, res))
+	}
+
+	http.ServeContent(w, r, res.Options().Name, res.ModTime(), res.Reader())
+}
+
+// itemETag returns the strong ETag of the item's version.
+func itemETag(key string, res *filecache.OpenResult) string {
+	h := sha256.New()
+
+	_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d", key, res.ModTime().UnixNano(), res.Size())
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
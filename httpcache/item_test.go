@@ -0,0 +1,75 @@
+package httpcache_test
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/kukymbr/filecache/v2/httpcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemHandler(t *testing.T) {
+	value := strings.Repeat("0123456789", 10000)
+
+	for _, codec := range []filecache.Codec{nil, filecache.NewGzipCodec(gzip.BestSpeed)} {
+		fc, err := filecache.New(t.TempDir(), filecache.InstanceOptions{Codec: codec})
+		require.NoError(t, err)
+
+		_, err = fc.WriteData(context.Background(), "/item", []byte(value), filecache.ItemOptions{Name: "item.txt"})
+		require.NoError(t, err)
+
+		h := httpcache.ItemHandler(fc, nil)
+
+		full := serve(h, http.MethodGet, "/item", nil)
+
+		assert.Equal(t, http.StatusOK, full.Code)
+		assert.Equal(t, value, full.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", full.Header().Get("Content-Type"))
+		assert.NotEmpty(t, full.Header().Get("Last-Modified"))
+
+		etag := full.Header().Get("ETag")
+		assert.True(t, strings.HasPrefix(etag, `"`))
+
+		partial := serve(h, http.MethodGet, "/item", http.Header{"Range": {"bytes=50005-50014"}})
+
+		assert.Equal(t, http.StatusPartialContent, partial.Code)
+		assert.Equal(t, "5678901234", partial.Body.String())
+
+		notModified := serve(h, http.MethodGet, "/item", http.Header{"If-None-Match": {etag}})
+
+		assert.Equal(t, http.StatusNotModified, notModified.Code)
+
+		notFound := serve(h, http.MethodGet, "/unknown", nil)
+
+		assert.Equal(t, http.StatusNotFound, notFound.Code)
+
+		_, err = fc.WriteData(context.Background(), "/item", []byte(value))
+		require.NoError(t, err)
+
+		rewritten := serve(h, http.MethodGet, "/item", http.Header{"If-None-Match": {etag}})
+
+		assert.Equal(t, http.StatusOK, rewritten.Code)
+		assert.NotEqual(t, etag, rewritten.Header().Get("ETag"))
+	}
+}
+
+func TestServeItem_WhenOpenFails_ExpectServerError(t *testing.T) {
+	fc, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	httpcache.ServeItem(w, r, fc, "key")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
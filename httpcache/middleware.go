@@ -0,0 +1,309 @@
+// Package httpcache provides the HTTP caching tools backed by the filecache.FileCache.
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// MiddlewareOptions are the Middleware options.
+type MiddlewareOptions struct {
+	// KeyFunc returns the cache key of the request.
+	// By default, the key is the request's host and URI.
+	KeyFunc func(r *http.Request) string
+
+	// DefaultTTL is a time to cache the responses without the explicit freshness lifetime
+	// (the Cache-Control's max-age or s-maxage directives or the Expires header).
+	// If zero, such responses are not cached.
+	DefaultTTL time.Duration
+
+	// Statuses are the cacheable responses' status codes.
+	// By default, the codes cacheable by default according to the RFC 9111 are used:
+	// 200, 203, 204, 300, 301, 308, 404, 405, 410, 414 and 501.
+	Statuses []int
+}
+
+// defaultStatuses are the status codes cacheable by default, see the RFC 9110, section 15.1.
+var defaultStatuses = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusGone,
+	http.StatusRequestURITooLong,
+	http.StatusNotImplemented,
+}
+
+// Middleware returns the HTTP middleware caching the responses of the handler in the FileCache
+// as a shared cache.
+//
+// The responses to the GET requests are cached with their status and headers stored in the item's fields,
+// if they are cacheable according to their Cache-Control header, status code and the options.
+// The HEAD requests are served from the cached GET responses.
+// The responses varying on the request headers (the Vary header) are cached per headers' values.
+//
+// The requests with the Cache-Control's no-store directive, the Authorization or Range headers
+// bypass the cache; the no-cache directive makes the request skip the cached response.
+// The responses with the Cache-Control's no-store, no-cache or private directives
+// or with the Set-Cookie header are not cached.
+func Middleware(fc filecache.FileCache, options ...MiddlewareOptions) func(http.Handler) http.Handler {
+	opt := MiddlewareOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	m := &middleware{
+		fc:         fc,
+		keyFunc:    opt.KeyFunc,
+		defaultTTL: opt.DefaultTTL,
+		statuses:   make(map[int]bool),
+	}
+
+	if m.keyFunc == nil {
+		m.keyFunc = defaultKey
+	}
+
+	statuses := opt.Statuses
+	if statuses == nil {
+		statuses = defaultStatuses
+	}
+
+	for _, status := range statuses {
+		m.statuses[status] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serveHTTP(next, w, r)
+		})
+	}
+}
+
+func defaultKey(r *http.Request) string {
+	return r.Host + r.URL.RequestURI()
+}
+
+type middleware struct {
+	fc         filecache.FileCache
+	keyFunc    func(r *http.Request) string
+	defaultTTL time.Duration
+	statuses   map[int]bool
+}
+
+func (m *middleware) serveHTTP(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	reqCC := parseCacheControl(r.Header)
+
+	if !isCacheableRequest(r, reqCC) {
+		next.ServeHTTP(w, r)
+
+		return
+	}
+
+	key := m.keyFunc(r)
+
+	if !reqCC.has("no-cache") && m.serveCached(w, r, key, reqCC) {
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		next.ServeHTTP(w, r)
+
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: w, m: m, r: r, key: key}
+
+	// The writer is aborted if the handler panics.
+	defer rec.abort()
+
+	next.ServeHTTP(rec, r)
+
+	rec.finish()
+}
+
+func isCacheableRequest(r *http.Request, cc cacheControl) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	return !cc.has("no-store") && r.Header.Get("Authorization") == "" && r.Header.Get("Range") == ""
+}
+
+// serveCached writes the cached response, if it's found.
+func (m *middleware) serveCached(w http.ResponseWriter, r *http.Request, key string, reqCC cacheControl) bool {
+	res, e := openEntry(m.fc, r, key)
+	if res == nil {
+		return false
+	}
+
+	defer func() {
+		_ = res.Reader().Close()
+	}()
+
+	age := e.age(time.Now())
+
+	if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+		return false
+	}
+
+	header := w.Header()
+
+	for name, values := range e.header {
+		header[name] = values
+	}
+
+	header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	w.WriteHeader(e.status)
+
+	if r.Method != http.MethodHead {
+		_, _ = io.Copy(w, res.Reader())
+	}
+
+	return true
+}
+
+// responseRecorder is a http.ResponseWriter staging the cacheable response while sending it.
+// The response is written to the cache when the handler finishes, so the item's key is not locked
+// while the response is sent to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	m   *middleware
+	r   *http.Request
+	key string
+
+	wroteHeader bool
+	writer      *stagedWriter
+	ttl         time.Duration
+	vary        []string
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		rec.ResponseWriter.WriteHeader(status)
+
+		return
+	}
+
+	rec.wroteHeader = true
+	rec.openWriter(status)
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(p []byte) (n int, err error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	n, err = rec.ResponseWriter.Write(p)
+
+	if rec.writer != nil {
+		if err != nil {
+			rec.abort()
+		} else if _, err := rec.writer.Write(p[:n]); err != nil {
+			rec.abort()
+		}
+	}
+
+	return n, err
+}
+
+// Flush sends the buffered data to the client, if the underlying writer supports it.
+func (rec *responseRecorder) Flush() {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for the http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// openWriter opens the cache item's staged writer if the response is cacheable.
+func (rec *responseRecorder) openWriter(status int) {
+	header := rec.Header()
+
+	if !rec.m.statuses[status] || header.Get("Set-Cookie") != "" {
+		return
+	}
+
+	cc := parseCacheControl(header)
+	if cc.has("no-store") || cc.has("no-cache") || cc.has("private") {
+		return
+	}
+
+	vary, ok := varyNames(header)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	ttl, ok := freshness(cc, header, now, true)
+	if !ok {
+		ttl = rec.m.defaultTTL
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	key := rec.key
+	if len(vary) > 0 {
+		key = varyKey(key, vary, rec.r)
+	}
+
+	e := &entry{status: status, header: storedHeader(header), storedAt: now, lifetime: ttl}
+
+	rec.writer = newStagedWriter(rec.r.Context(), rec.m.fc, key, filecache.ItemOptions{
+		TTL:    ttl,
+		Fields: e.fields(),
+	})
+	rec.ttl = ttl
+	rec.vary = vary
+}
+
+// finish commits the cached response.
+// The response varying on the request headers is committed with a marker listing the headers.
+func (rec *responseRecorder) finish() {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if rec.writer == nil {
+		return
+	}
+
+	w := rec.writer
+	rec.writer = nil
+
+	if err := w.Commit(); err != nil || len(rec.vary) == 0 {
+		return
+	}
+
+	writeVaryMarker(rec.r.Context(), rec.m.fc, rec.key, rec.vary, rec.ttl)
+}
+
+// abort discards the cached response.
+func (rec *responseRecorder) abort() {
+	if rec.writer == nil {
+		return
+	}
+
+	rec.writer.Abort()
+	rec.writer = nil
+}
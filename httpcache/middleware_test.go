@@ -0,0 +1,274 @@
+package httpcache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/kukymbr/filecache/v2/httpcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T) filecache.FileCache {
+	fc, err := filecache.New(t.TempDir())
+	require.NoError(t, err)
+
+	return fc
+}
+
+// newCountingHandler returns the handler responding with the calls counter in the body.
+func newCountingHandler(calls *atomic.Int32, header http.Header, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+
+		w.Header().Set("X-Accept", r.Header.Get("Accept"))
+		w.WriteHeader(status)
+
+		_, _ = w.Write([]byte("response " + strconv.Itoa(int(n))))
+	})
+}
+
+func serve(h http.Handler, method string, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestMiddleware_WhenCacheable_ExpectCached(t *testing.T) {
+	var calls atomic.Int32
+
+	h := httpcache.Middleware(newTestCache(t))(newCountingHandler(&calls, http.Header{
+		"Cache-Control": {"public, max-age=60"},
+		"Content-Type":  {"text/plain"},
+		"Connection":    {"X-Hop"},
+		"X-Hop":         {"hop"},
+	}, http.StatusOK))
+
+	first := serve(h, http.MethodGet, "/path?q=1", nil)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "response 1", first.Body.String())
+
+	second := serve(h, http.MethodGet, "/path?q=1", nil)
+
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "response 1", second.Body.String())
+	assert.Equal(t, "text/plain", second.Header().Get("Content-Type"))
+	assert.Equal(t, "0", second.Header().Get("Age"))
+	assert.Empty(t, second.Header().Get("X-Hop"))
+
+	head := serve(h, http.MethodHead, "/path?q=1", nil)
+
+	assert.Equal(t, http.StatusOK, head.Code)
+	assert.Empty(t, head.Body.String())
+	assert.Equal(t, "text/plain", head.Header().Get("Content-Type"))
+
+	other := serve(h, http.MethodGet, "/path?q=2", nil)
+
+	assert.Equal(t, "response 2", other.Body.String())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMiddleware_WhenNotCacheable_ExpectNotCached(t *testing.T) {
+	tests := map[string]struct {
+		method    string
+		reqHeader http.Header
+		header    http.Header
+		status    int
+	}{
+		"no-store response": {
+			header: http.Header{"Cache-Control": {"no-store"}},
+		},
+		"private response": {
+			header: http.Header{"Cache-Control": {"private, max-age=60"}},
+		},
+		"no freshness": {},
+		"expired": {
+			header: http.Header{"Expires": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}},
+		},
+		"set-cookie": {
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Set-Cookie": {"a=b"}},
+		},
+		"vary any": {
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+		},
+		"error status": {
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+			status: http.StatusInternalServerError,
+		},
+		"post request": {
+			method: http.MethodPost,
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		"no-store request": {
+			reqHeader: http.Header{"Cache-Control": {"no-store"}},
+			header:    http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		"authorized request": {
+			reqHeader: http.Header{"Authorization": {"Bearer token"}},
+			header:    http.Header{"Cache-Control": {"max-age=60"}},
+		},
+	}
+
+	for name, test := range tests {
+		var calls atomic.Int32
+
+		if test.method == "" {
+			test.method = http.MethodGet
+		}
+
+		if test.status == 0 {
+			test.status = http.StatusOK
+		}
+
+		h := httpcache.Middleware(newTestCache(t))(newCountingHandler(&calls, test.header, test.status))
+
+		serve(h, test.method, "/", test.reqHeader)
+		res := serve(h, test.method, "/", test.reqHeader)
+
+		assert.Equal(t, test.status, res.Code, name)
+		assert.Equal(t, "response 2", res.Body.String(), name)
+	}
+}
+
+func TestMiddleware_WhenDefaultTTL_ExpectCached(t *testing.T) {
+	var calls atomic.Int32
+
+	h := httpcache.Middleware(newTestCache(t), httpcache.MiddlewareOptions{
+		DefaultTTL: time.Minute,
+		KeyFunc: func(r *http.Request) string {
+			return r.URL.Path
+		},
+	})(newCountingHandler(&calls, nil, http.StatusNotFound))
+
+	serve(h, http.MethodGet, "/?q=1", nil)
+	res := serve(h, http.MethodGet, "/?q=2", nil)
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "response 1", res.Body.String())
+}
+
+func TestMiddleware_WhenRequestNoCache_ExpectRefreshed(t *testing.T) {
+	var calls atomic.Int32
+
+	h := httpcache.Middleware(newTestCache(t))(newCountingHandler(&calls, http.Header{
+		"Cache-Control": {"max-age=60"},
+	}, http.StatusOK))
+
+	serve(h, http.MethodGet, "/", nil)
+
+	res := serve(h, http.MethodGet, "/", http.Header{"Cache-Control": {"no-cache"}})
+	assert.Equal(t, "response 2", res.Body.String())
+
+	res = serve(h, http.MethodGet, "/", nil)
+	assert.Equal(t, "response 2", res.Body.String())
+}
+
+func TestMiddleware_WhenVary_ExpectVariantsCached(t *testing.T) {
+	var calls atomic.Int32
+
+	h := httpcache.Middleware(newTestCache(t))(newCountingHandler(&calls, http.Header{
+		"Cache-Control": {"max-age=60"},
+		"Vary":          {"accept"},
+	}, http.StatusOK))
+
+	jsonHeader := http.Header{"Accept": {"application/json"}}
+	xmlHeader := http.Header{"Accept": {"application/xml"}}
+
+	assert.Equal(t, "response 1", serve(h, http.MethodGet, "/", jsonHeader).Body.String())
+	assert.Equal(t, "response 2", serve(h, http.MethodGet, "/", xmlHeader).Body.String())
+
+	res := serve(h, http.MethodGet, "/", jsonHeader)
+
+	assert.Equal(t, "response 1", res.Body.String())
+	assert.Equal(t, "application/json", res.Header().Get("X-Accept"))
+
+	res = serve(h, http.MethodGet, "/", xmlHeader)
+
+	assert.Equal(t, "response 2", res.Body.String())
+	assert.Equal(t, "application/xml", res.Header().Get("X-Accept"))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMiddleware_WhenHandlerPanics_ExpectNotCached(t *testing.T) {
+	fc := newTestCache(t)
+
+	h := httpcache.Middleware(fc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("partial"))
+
+		panic("handler failed")
+	}))
+
+	assert.Panics(t, func() {
+		serve(h, http.MethodGet, "/", nil)
+	})
+
+	var calls atomic.Int32
+
+	h = httpcache.Middleware(fc)(newCountingHandler(&calls, nil, http.StatusOK))
+
+	assert.Equal(t, "response 1", serve(h, http.MethodGet, "/", nil).Body.String())
+}
+
+func TestMiddleware_WhenResponseBeingSent_ExpectNotBlocked(t *testing.T) {
+	var calls atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	counting := newCountingHandler(&calls, http.Header{"Cache-Control": {"max-age=60"}}, http.StatusOK)
+
+	h := httpcache.Middleware(newTestCache(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Slow") != "" {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte("slow "))
+
+			close(started)
+			<-release
+		}
+
+		counting.ServeHTTP(w, r)
+	}))
+
+	slow := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		slow <- serve(h, http.MethodGet, "/", http.Header{"X-Slow": {"1"}})
+	}()
+
+	<-started
+
+	fast := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		fast <- serve(h, http.MethodGet, "/", nil)
+	}()
+
+	select {
+	case res := <-fast:
+		assert.Equal(t, "response 1", res.Body.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request is blocked by the response being sent")
+	}
+
+	close(release)
+
+	assert.Equal(t, "slow response 2", (<-slow).Body.String())
+	assert.Equal(t, "slow response 2", serve(h, http.MethodGet, "/", nil).Body.String())
+}
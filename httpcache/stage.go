@@ -0,0 +1,86 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// stageMemoryLimit is the maximum size of the body staged in memory,
+// the larger bodies are staged in a temporary file.
+const stageMemoryLimit = 1024 * 1024
+
+// stagedWriter stages the response's body and writes it to the cache item when the body is complete,
+// so the item's key is not locked while the body is transferred over the network.
+type stagedWriter struct {
+	ctx     context.Context
+	fc      filecache.FileCache
+	key     string
+	options filecache.ItemOptions
+
+	buf  bytes.Buffer
+	file *os.File
+}
+
+func newStagedWriter(
+	ctx context.Context,
+	fc filecache.FileCache,
+	key string,
+	options filecache.ItemOptions,
+) *stagedWriter {
+	return &stagedWriter{ctx: ctx, fc: fc, key: key, options: options}
+}
+
+func (w *stagedWriter) Write(p []byte) (n int, err error) {
+	if w.file == nil && w.buf.Len()+len(p) <= stageMemoryLimit {
+		return w.buf.Write(p)
+	}
+
+	if w.file == nil {
+		f, err := os.CreateTemp("", "filecache-httpcache-*")
+		if err != nil {
+			return 0, err
+		}
+
+		w.file = f
+
+		if _, err := w.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+
+	return w.file.Write(p)
+}
+
+// Commit writes the staged body to the cache item and discards the stage.
+func (w *stagedWriter) Commit() error {
+	defer w.Abort()
+
+	var body io.Reader = &w.buf
+
+	if w.file != nil {
+		if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		body = w.file
+	}
+
+	_, err := w.fc.Write(w.ctx, w.key, body, w.options)
+
+	return err
+}
+
+// Abort discards the staged body.
+func (w *stagedWriter) Abort() {
+	w.buf.Reset()
+
+	if w.file != nil {
+		_ = w.file.Close()
+		_ = os.Remove(w.file.Name())
+		w.file = nil
+	}
+}
@@ -0,0 +1,46 @@
+package httpcache
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// openEntry opens the cached response to the request, selecting its variant if the response varies.
+// Returns nil result if the response is not found.
+func openEntry(fc filecache.FileCache, r *http.Request, key string) (*filecache.OpenResult, *entry) {
+	res, err := fc.Open(r.Context(), key)
+	if err != nil || !res.Hit() {
+		return nil, nil
+	}
+
+	e, ok := parseEntry(res.Options().Fields)
+	if ok && len(e.vary) > 0 {
+		_ = res.Reader().Close()
+
+		res, err = fc.Open(r.Context(), varyKey(key, e.vary, r))
+		if err != nil || !res.Hit() {
+			return nil, nil
+		}
+
+		e, ok = parseEntry(res.Options().Fields)
+	}
+
+	if !ok || len(e.vary) > 0 {
+		_ = res.Reader().Close()
+
+		return nil, nil
+	}
+
+	return res, e
+}
+
+// writeVaryMarker writes the marker of the response varying on the request headers,
+// listing the headers' names to select the response's variant by.
+func writeVaryMarker(ctx context.Context, fc filecache.FileCache, key string, vary []string, ttl time.Duration) {
+	marker := &entry{storedAt: time.Now(), vary: vary}
+
+	_, _ = fc.WriteData(ctx, key, nil, filecache.ItemOptions{TTL: ttl, Fields: marker.fields()})
+}
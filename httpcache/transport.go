@@ -0,0 +1,416 @@
+package httpcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+)
+
+// XFromCache is a header set to "1" in the responses served from the cache by the Transport,
+// including the responses revalidated with the origin server.
+const XFromCache = "X-From-Cache"
+
+// TransportOptions are the Transport options.
+type TransportOptions struct {
+	// Transport is the http.RoundTripper sending the requests to the origin server.
+	// The http.DefaultTransport is used by default.
+	Transport http.RoundTripper
+
+	// KeyFunc returns the cache key of the request.
+	// By default, the key is the request's URL.
+	KeyFunc func(r *http.Request) string
+}
+
+// NewTransport returns the http.RoundTripper caching the responses in the FileCache as a private cache,
+// following the RFC 9111 semantics:
+//   - the responses to the GET requests are cached, if their Cache-Control header and status code allow it;
+//   - the cached response is fresh for the lifetime defined by the Cache-Control's max-age directive
+//     or the Expires header, or for the 10% of the time since its Last-Modified date;
+//   - the stale responses with the ETag or Last-Modified headers are revalidated
+//     with the conditional requests, the validators are stored in the item's fields;
+//   - the responses varying on the request headers (the Vary header) are cached per headers' values.
+//
+// The response's body is staged while the caller reads it (in memory or, if it's large, in a temporary file),
+// and the item is written when the body is read to the end, so the item's key is not locked
+// while the body is transferred. The body must be always read or closed to discard the staged data,
+// as required by the http.Client anyway.
+//
+// The responses with validators are cached with the FileCache's default TTL, so they can be revalidated
+// when stale; the responses without validators are cached for their freshness lifetime.
+func NewTransport(fc filecache.FileCache, options ...TransportOptions) http.RoundTripper {
+	opt := TransportOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	t := &transport{
+		fc:      fc,
+		next:    opt.Transport,
+		keyFunc: opt.KeyFunc,
+	}
+
+	if t.next == nil {
+		t.next = http.DefaultTransport
+	}
+
+	if t.keyFunc == nil {
+		t.keyFunc = func(r *http.Request) string {
+			return r.URL.String()
+		}
+	}
+
+	return t
+}
+
+type transport struct {
+	fc      filecache.FileCache
+	next    http.RoundTripper
+	keyFunc func(r *http.Request) string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header)
+
+	if !isCacheableClientRequest(req, reqCC) {
+		return t.next.RoundTrip(req)
+	}
+
+	key := t.keyFunc(req)
+	now := time.Now()
+
+	res, e := openEntry(t.fc, req, key)
+	if res != nil && isUsable(e, reqCC, now) {
+		return cachedResponse(req, e, res.Reader(), now), nil
+	}
+
+	if reqCC.has("only-if-cached") {
+		if res != nil {
+			_ = res.Reader().Close()
+		}
+
+		return gatewayTimeoutResponse(req), nil
+	}
+
+	if res != nil && hasValidators(e.header) {
+		return t.revalidate(req, key, res.Reader(), e)
+	}
+
+	if res != nil {
+		_ = res.Reader().Close()
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.store(req, key, resp), nil
+}
+
+// conditionalHeaders are the headers of the conditional & range requests.
+var conditionalHeaders = []string{
+	"Range",
+	"If-Match",
+	"If-None-Match",
+	"If-Modified-Since",
+	"If-Unmodified-Since",
+	"If-Range",
+}
+
+func isCacheableClientRequest(req *http.Request, cc cacheControl) bool {
+	if req.Method != http.MethodGet || cc.has("no-store") {
+		return false
+	}
+
+	// The requests with their own conditions are passed as is.
+	for _, name := range conditionalHeaders {
+		if req.Header.Get(name) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUsable returns true if the cached entry might be returned without the revalidation.
+func isUsable(e *entry, reqCC cacheControl, now time.Time) bool {
+	if reqCC.has("no-cache") || !e.isFresh(now) {
+		return false
+	}
+
+	maxAge, ok := reqCC.seconds("max-age")
+
+	return !ok || e.age(now) <= maxAge
+}
+
+func hasValidators(h http.Header) bool {
+	return h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// revalidate sends the conditional request validating the cached entry.
+// If the entry is not modified, it's returned with the updated headers & freshness.
+func (t *transport) revalidate(req *http.Request, key string, cached io.ReadCloser, e *entry) (*http.Response, error) {
+	creq := req.Clone(req.Context())
+
+	if etag := e.header.Get("ETag"); etag != "" {
+		creq.Header.Set("If-None-Match", etag)
+	}
+
+	if lastModified := e.header.Get("Last-Modified"); lastModified != "" {
+		creq.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := t.next.RoundTrip(creq)
+	if err != nil {
+		_ = cached.Close()
+
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusNotModified {
+		_ = cached.Close()
+
+		return t.store(req, key, resp), nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	now := time.Now()
+	header := e.header.Clone()
+
+	for name, values := range storedHeader(resp.Header) {
+		if name != "Content-Length" {
+			header[name] = values
+		}
+	}
+
+	updated := &entry{status: e.status, header: header, storedAt: initialTime(resp.Header, now)}
+	updated.lifetime = lifetime(updated.status, header, now)
+
+	// The cached body is rewritten with the updated entry while the caller reads it.
+	body := t.tee(req, key, updated, cached)
+
+	return cachedResponse(req, updated, body, now), nil
+}
+
+// store writes the response to the cache while the caller reads its body, if the response is cacheable.
+func (t *transport) store(req *http.Request, key string, resp *http.Response) *http.Response {
+	if !isStorable(resp) {
+		return resp
+	}
+
+	now := time.Now()
+	header := storedHeader(resp.Header)
+
+	e := &entry{status: resp.StatusCode, header: header, storedAt: initialTime(resp.Header, now)}
+	e.lifetime = lifetime(e.status, header, now)
+
+	if e.lifetime <= 0 && !hasValidators(header) {
+		return resp
+	}
+
+	resp.Body = t.tee(req, key, e, resp.Body)
+
+	return resp
+}
+
+func isStorable(resp *http.Response) bool {
+	if !isDefaultStatus(resp.StatusCode) {
+		return false
+	}
+
+	if parseCacheControl(resp.Header).has("no-store") {
+		return false
+	}
+
+	_, ok := varyNames(resp.Header)
+
+	return ok
+}
+
+func isDefaultStatus(status int) bool {
+	for _, s := range defaultStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lifetime returns the freshness lifetime of the response for a private cache.
+// If the lifetime is not defined explicitly, the heuristic one is used: 10% of the time since the Last-Modified date.
+func lifetime(status int, h http.Header, now time.Time) time.Duration {
+	cc := parseCacheControl(h)
+
+	if cc.has("no-cache") {
+		return 0
+	}
+
+	if d, ok := freshness(cc, h, now, false); ok {
+		return d
+	}
+
+	lastModified, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil || !isDefaultStatus(status) {
+		return 0
+	}
+
+	date := now
+
+	if d, err := http.ParseTime(h.Get("Date")); err == nil {
+		date = d
+	}
+
+	return date.Sub(lastModified) / 10
+}
+
+// initialTime returns the time the response has been generated by the origin server,
+// the response's age is counted from, using the response's Date and Age headers.
+func initialTime(h http.Header, now time.Time) time.Time {
+	initial := now
+
+	if date, err := http.ParseTime(h.Get("Date")); err == nil && date.Before(initial) {
+		initial = date
+	}
+
+	if age, err := strconv.ParseInt(h.Get("Age"), 10, 64); err == nil && age > 0 {
+		if generated := now.Add(-time.Duration(age) * time.Second); generated.Before(initial) {
+			initial = generated
+		}
+	}
+
+	return initial
+}
+
+// tee returns the body staging the read data for the cache item of the entry.
+// The item is written when the body is read to the end, or discarded on the reading error or early close.
+func (t *transport) tee(req *http.Request, key string, e *entry, body io.ReadCloser) io.ReadCloser {
+	vary, _ := varyNames(e.header)
+
+	itemKey := key
+	if len(vary) > 0 {
+		itemKey = varyKey(key, vary, req)
+	}
+
+	ttl := e.lifetime
+	if hasValidators(e.header) {
+		// The item is kept after it's stale to be revalidated, the FileCache's default TTL is used.
+		ttl = 0
+	}
+
+	w := newStagedWriter(req.Context(), t.fc, itemKey, filecache.ItemOptions{TTL: ttl, Fields: e.fields()})
+	cb := &cachingBody{body: body, writer: w}
+
+	if len(vary) > 0 {
+		cb.onCommit = func() {
+			writeVaryMarker(req.Context(), t.fc, key, vary, ttl)
+		}
+	}
+
+	return cb
+}
+
+// cachedResponse returns the response of the cached entry.
+func cachedResponse(req *http.Request, e *entry, body io.ReadCloser, now time.Time) *http.Response {
+	header := e.header.Clone()
+
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	header.Set(XFromCache, "1")
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}
+
+	if length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = length
+	}
+
+	return resp
+}
+
+// gatewayTimeoutResponse returns the response to the only-if-cached request, which is not cached.
+func gatewayTimeoutResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)),
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}
+}
+
+// cachingBody is a response body staging the read data for the cache item.
+type cachingBody struct {
+	body     io.ReadCloser
+	writer   *stagedWriter
+	onCommit func()
+}
+
+func (b *cachingBody) Read(p []byte) (n int, err error) {
+	n, err = b.body.Read(p)
+
+	if b.writer == nil {
+		return n, err
+	}
+
+	if n > 0 {
+		if _, werr := b.writer.Write(p[:n]); werr != nil {
+			b.abort()
+
+			return n, err
+		}
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		b.commit()
+	case err != nil:
+		b.abort()
+	}
+
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	b.abort()
+
+	return b.body.Close()
+}
+
+func (b *cachingBody) commit() {
+	w := b.writer
+	b.writer = nil
+
+	if err := w.Commit(); err == nil && b.onCommit != nil {
+		b.onCommit()
+	}
+}
+
+func (b *cachingBody) abort() {
+	if b.writer == nil {
+		return
+	}
+
+	b.writer.Abort()
+	b.writer = nil
+}
@@ -0,0 +1,272 @@
+package httpcache_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/httpcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOrigin struct {
+	calls       atomic.Int32
+	notModified atomic.Int32
+	header      http.Header
+	body        []byte
+	etag        string
+}
+
+func (o *testOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.calls.Add(1)
+
+	if o.etag != "" && r.Header.Get("If-None-Match") == o.etag {
+		o.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	for name, values := range o.header {
+		w.Header()[name] = values
+	}
+
+	if o.etag != "" {
+		w.Header().Set("ETag", o.etag)
+	}
+
+	w.Header().Set("X-Call", strconv.Itoa(int(o.calls.Load())))
+	w.Header().Set("X-Accept", r.Header.Get("Accept"))
+
+	_, _ = w.Write(o.body)
+}
+
+func newTestClient(t *testing.T, origin *testOrigin) (*http.Client, string) {
+	server := httptest.NewServer(origin)
+	t.Cleanup(server.Close)
+
+	return &http.Client{Transport: httpcache.NewTransport(newTestCache(t))}, server.URL
+}
+
+func get(t *testing.T, client *http.Client, url string, header http.Header) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, string(body)
+}
+
+func TestTransport_WhenFresh_ExpectCached(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Cache-Control": {"max-age=60"}},
+		body:   bytes.Repeat([]byte("large body "), 100000),
+	}
+	client, url := newTestClient(t, origin)
+
+	first, body := get(t, client, url, nil)
+
+	assert.Equal(t, string(origin.body), body)
+	assert.Empty(t, first.Header.Get(httpcache.XFromCache))
+
+	second, body := get(t, client, url, nil)
+
+	assert.Equal(t, string(origin.body), body)
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "1", second.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, "1", second.Header.Get("X-Call"))
+	assert.Equal(t, int32(1), origin.calls.Load())
+
+	// The request's no-cache directive makes the response refreshed.
+	_, _ = get(t, client, url, http.Header{"Cache-Control": {"no-cache"}})
+
+	third, _ := get(t, client, url, nil)
+
+	assert.Equal(t, "2", third.Header.Get("X-Call"))
+	assert.Equal(t, int32(2), origin.calls.Load())
+}
+
+func TestTransport_WhenBodyNotRead_ExpectNotCached(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Cache-Control": {"max-age=60"}},
+		body:   bytes.Repeat([]byte("large body "), 100000),
+	}
+	client, url := newTestClient(t, origin)
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+
+	_, err = resp.Body.Read(make([]byte, 100))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	resp, body := get(t, client, url, nil)
+
+	assert.Equal(t, string(origin.body), body)
+	assert.Empty(t, resp.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, int32(2), origin.calls.Load())
+}
+
+func TestTransport_WhenBodyBeingRead_ExpectNotBlocked(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Cache-Control": {"max-age=60"}},
+		body:   bytes.Repeat([]byte("large body "), 100000),
+	}
+	client, url := newTestClient(t, origin)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		first, err := client.Get(url)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// The second request is sent while the first response's body is not read yet.
+		second, body := get(t, client, url, nil)
+
+		assert.Equal(t, string(origin.body), body)
+		assert.Empty(t, second.Header.Get(httpcache.XFromCache))
+
+		body1, err := io.ReadAll(first.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, first.Body.Close())
+		assert.Equal(t, string(origin.body), string(body1))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second request is blocked by the first one")
+	}
+
+	third, body := get(t, client, url, nil)
+
+	assert.Equal(t, string(origin.body), body)
+	assert.Equal(t, "1", third.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, int32(2), origin.calls.Load())
+}
+
+func TestTransport_WhenStale_ExpectRevalidated(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Cache-Control": {"no-cache"}},
+		body:   []byte("body"),
+		etag:   `"v1"`,
+	}
+	client, url := newTestClient(t, origin)
+
+	_, _ = get(t, client, url, nil)
+
+	for i := 0; i < 2; i++ {
+		resp, body := get(t, client, url, nil)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "body", body)
+		assert.Equal(t, "1", resp.Header.Get(httpcache.XFromCache))
+		assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+	}
+
+	assert.Equal(t, int32(3), origin.calls.Load())
+	assert.Equal(t, int32(2), origin.notModified.Load())
+
+	origin.etag = `"v2"`
+	origin.body = []byte("new body")
+
+	resp, body := get(t, client, url, nil)
+
+	assert.Equal(t, "new body", body)
+	assert.Empty(t, resp.Header.Get(httpcache.XFromCache))
+
+	resp, body = get(t, client, url, nil)
+
+	assert.Equal(t, "new body", body)
+	assert.Equal(t, "1", resp.Header.Get(httpcache.XFromCache))
+}
+
+func TestTransport_WhenLastModified_ExpectHeuristicFreshness(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Last-Modified": {time.Now().Add(-24 * time.Hour).UTC().Format(http.TimeFormat)}},
+		body:   []byte("body"),
+	}
+	client, url := newTestClient(t, origin)
+
+	_, _ = get(t, client, url, nil)
+	resp, body := get(t, client, url, nil)
+
+	assert.Equal(t, "body", body)
+	assert.Equal(t, "1", resp.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, int32(1), origin.calls.Load())
+}
+
+func TestTransport_WhenNotStorable_ExpectNotCached(t *testing.T) {
+	headers := map[string]http.Header{
+		"no-store":    {"Cache-Control": {"max-age=60, no-store"}},
+		"no lifetime": {},
+		"expired":     {"Expires": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}},
+		"aged":        {"Cache-Control": {"max-age=60"}, "Age": {"120"}},
+	}
+
+	for name, header := range headers {
+		origin := &testOrigin{header: header, body: []byte("body")}
+		client, url := newTestClient(t, origin)
+
+		_, _ = get(t, client, url, nil)
+		resp, _ := get(t, client, url, nil)
+
+		assert.Empty(t, resp.Header.Get(httpcache.XFromCache), name)
+		assert.Equal(t, int32(2), origin.calls.Load(), name)
+	}
+}
+
+func TestTransport_WhenOnlyIfCached_ExpectGatewayTimeout(t *testing.T) {
+	client, url := newTestClient(t, &testOrigin{body: []byte("body")})
+
+	resp, _ := get(t, client, url, http.Header{"Cache-Control": {"only-if-cached"}})
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestTransport_WhenVary_ExpectVariantsCached(t *testing.T) {
+	origin := &testOrigin{
+		header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}},
+		body:   []byte("body"),
+	}
+	client, url := newTestClient(t, origin)
+
+	jsonHeader := http.Header{"Accept": {"application/json"}}
+	xmlHeader := http.Header{"Accept": {"application/xml"}}
+
+	_, _ = get(t, client, url, jsonHeader)
+	_, _ = get(t, client, url, xmlHeader)
+
+	resp, _ := get(t, client, url, jsonHeader)
+
+	assert.Equal(t, "1", resp.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, "application/json", resp.Header.Get("X-Accept"))
+
+	resp, _ = get(t, client, url, xmlHeader)
+
+	assert.Equal(t, "1", resp.Header.Get(httpcache.XFromCache))
+	assert.Equal(t, "application/xml", resp.Header.Get("X-Accept"))
+	assert.Equal(t, int32(2), origin.calls.Load())
+}
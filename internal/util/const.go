@@ -10,6 +10,7 @@ const (
 	TTLEternal = time.Duration(-1)
 
 	MetaSuffix = "--meta"
+	TempSuffix = ".tmp"
 
 	DirsMode  os.FileMode = 0755
 	FilesMode os.FileMode = 0644
@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrDirNotExists = errors.New("directory does not exist")
 	ErrNotADir      = errors.New("not a directory")
+
+	ErrFileLockingUnsupported = errors.New("file locking is not supported on this platform")
 )
@@ -3,6 +3,7 @@ package util
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,20 +12,20 @@ import (
 
 type PathGeneratorFn func(key string) string
 
-// DeleteCacheFiles removes cache files
-func DeleteCacheFiles(paths ...string) {
+// DeleteCacheFiles removes cache files in the given order.
+func DeleteCacheFiles(fsys FS, paths ...string) {
 	if len(paths) > 2 {
 		panic("unexpected behaviour: DeleteCacheFiles expects no more than two paths")
 	}
 
 	for _, path := range paths {
-		_ = os.Remove(path)
+		_ = fsys.Remove(path)
 	}
 }
 
 // PrepareDir checks if dir exists and creates it otherwise.
-func PrepareDir(dir string) error {
-	err := validateDir(dir)
+func PrepareDir(fsys FS, dir string) error {
+	err := validateDir(fsys, dir)
 
 	if err == nil {
 		return nil
@@ -34,7 +35,7 @@ func PrepareDir(dir string) error {
 		return err
 	}
 
-	if err = os.MkdirAll(dir, DirsMode); err != nil {
+	if err = fsys.MkdirAll(dir); err != nil {
 		return fmt.Errorf("%s dir does not exist and cannot be created: %w", dir, err)
 	}
 
@@ -42,10 +43,10 @@ func PrepareDir(dir string) error {
 }
 
 // validateDir checks if a given path is an existing dir path.
-func validateDir(dir string) error {
-	stat, err := os.Stat(dir)
+func validateDir(fsys FS, dir string) error {
+	stat, err := fsys.Stat(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return fmt.Errorf("%s: %w", dir, ErrDirNotExists)
 		}
 
@@ -60,22 +61,72 @@ func validateDir(dir string) error {
 }
 
 // ItemFilesValid checks if itemPath & metaPath are a valid files' paths.
-func ItemFilesValid(itemPath string, metaPath string) bool {
+func ItemFilesValid(fsys FS, itemPath string, metaPath string) bool {
+	_, _, ok := StatItemFiles(fsys, itemPath, metaPath)
+
+	return ok
+}
+
+// StatItemFiles returns the stats of the item & meta files if they are a valid files' paths.
+func StatItemFiles(fsys FS, itemPath string, metaPath string) (itemStat fs.FileInfo, metaStat fs.FileInfo, ok bool) {
 	if itemPath == "" || metaPath == "" {
-		return false
+		return nil, nil, false
 	}
 
-	itemStat, err := os.Stat(itemPath)
+	itemStat, err := fsys.Stat(itemPath)
 	if err != nil {
-		return false
+		return nil, nil, false
 	}
 
-	metaStat, err := os.Stat(metaPath)
+	metaStat, err = fsys.Stat(metaPath)
 	if err != nil {
-		return false
+		return nil, nil, false
+	}
+
+	if itemStat.IsDir() || metaStat.IsDir() {
+		return nil, nil, false
+	}
+
+	return itemStat, metaStat, true
+}
+
+// TouchFile sets the file's access & modification times to the current time.
+func TouchFile(fsys FS, path string) error {
+	now := time.Now()
+
+	return fsys.Chtimes(path, now, now)
+}
+
+// AnyFileExists checks if any of the given paths exists.
+func AnyFileExists(fsys FS, paths ...string) bool {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		if _, err := fsys.Stat(path); err == nil {
+			return true
+		}
 	}
 
-	return !itemStat.IsDir() && !metaStat.IsDir()
+	return false
+}
+
+// RemoveEmptyDirs removes the empty dirs and their empty parents up to the root dir (exclusive).
+func RemoveEmptyDirs(fsys FS, root string, dirs ...string) {
+	root = filepath.Clean(root)
+
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+
+		for dir != root && strings.HasPrefix(dir, root+string(os.PathSeparator)) {
+			if err := fsys.Remove(dir); err != nil {
+				break
+			}
+
+			dir = filepath.Dir(dir)
+		}
+	}
 }
 
 // FixSeparators replaces all path separators with the OS-correct.
@@ -107,12 +158,12 @@ func IsExpired(createdAt time.Time, ttl time.Duration) bool {
 }
 
 // GetItemPath returns full item's path.
-func GetItemPath(dir string, pathGenerator PathGeneratorFn, key string, forMeta bool, createDirs bool) string {
+func GetItemPath(fsys FS, dir string, pathGenerator PathGeneratorFn, key string, forMeta bool, createDirs bool) string {
 	path := filepath.Join(dir, pathGenerator(key))
 	itemDir := filepath.Dir(FixSeparators(path))
 
 	if itemDir != "." && createDirs {
-		_ = os.MkdirAll(itemDir, DirsMode)
+		_ = fsys.MkdirAll(itemDir)
 	}
 
 	if forMeta {
@@ -122,11 +173,53 @@ func GetItemPath(dir string, pathGenerator PathGeneratorFn, key string, forMeta
 	return path
 }
 
-func CreateCacheFile(key string, path string) (*os.File, error) {
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, FilesMode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create file for cache key %s: %w", key, err)
+// TempCacheFilePattern returns the dir and the name pattern (see the os.CreateTemp)
+// of the temporary file in the dir of the target path.
+// The file is published to the target path by the PublishCacheFiles function.
+func TempCacheFilePattern(path string) (dir string, pattern string) {
+	return filepath.Dir(path), "." + filepath.Base(path) + ".*" + TempSuffix
+}
+
+// TempCacheFileTarget returns the target path of the temporary file created by the TempCacheFilePattern.
+// The ok flag is false if the path is not a temporary cache file's path.
+func TempCacheFileTarget(path string) (target string, ok bool) {
+	name := filepath.Base(path)
+
+	if !strings.HasPrefix(name, ".") || !strings.HasSuffix(name, TempSuffix) {
+		return "", false
+	}
+
+	name = strings.TrimSuffix(name[1:], TempSuffix)
+
+	sep := strings.LastIndex(name, ".")
+	if sep <= 0 {
+		return "", false
 	}
 
-	return f, nil
+	for _, r := range name[sep+1:] {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+
+	return filepath.Join(filepath.Dir(path), name[:sep]), true
+}
+
+// PublishCacheFiles moves the temporary item & meta files to their target paths.
+// The item file is renamed first and the meta file is renamed last, replacing the old one,
+// so the meta file acts as a commit marker: until it's renamed, the readers see the old meta,
+// which doesn't belong to the new item file. If the meta file is not published,
+// the old one is removed, so the new item file is never paired with it.
+func PublishCacheFiles(fsys FS, key string, itemTmp string, itemPath string, metaTmp string, metaPath string) error {
+	if err := fsys.Rename(itemTmp, itemPath); err != nil {
+		return fmt.Errorf("failed to publish item file for cache key %s: %w", key, err)
+	}
+
+	if err := fsys.Rename(metaTmp, metaPath); err != nil {
+		_ = fsys.Remove(metaPath)
+
+		return fmt.Errorf("failed to publish meta file for cache key %s: %w", key, err)
+	}
+
+	return nil
 }
@@ -3,10 +3,13 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateDir_WhenValid_ExpectNoError(t *testing.T) {
@@ -18,7 +21,7 @@ func TestValidateDir_WhenValid_ExpectNoError(t *testing.T) {
 	}
 
 	for i, dir := range dirs {
-		err := validateDir(dir)
+		err := validateDir(OSFS{}, dir)
 
 		assert.NoError(t, err, i)
 		assert.DirExists(t, dir, i)
@@ -32,7 +35,7 @@ func TestValidateDir_WhenInvalid_ExpectError(t *testing.T) {
 	}
 
 	for i, dir := range dirs {
-		err := validateDir(dir)
+		err := validateDir(OSFS{}, dir)
 
 		assert.Error(t, err, i)
 		assert.NoDirExists(t, dir, i)
@@ -48,9 +51,9 @@ func TestPrepareDir_WhenValid_ExpectNoError(t *testing.T) {
 
 	for i, dir := range dirs {
 		dir := dir
-		existed := validateDir(dir) == nil
+		existed := validateDir(OSFS{}, dir) == nil
 
-		err := PrepareDir(dir)
+		err := PrepareDir(OSFS{}, dir)
 
 		assert.NoError(t, err, i)
 		assert.DirExists(t, dir, i)
@@ -69,7 +72,7 @@ func TestPrepareDir_WhenInvalid_ExpectError(t *testing.T) {
 	}
 
 	for i, dir := range dirs {
-		err := PrepareDir(dir)
+		err := PrepareDir(OSFS{}, dir)
 
 		assert.Error(t, err, i)
 		assert.NoDirExists(t, dir, i)
@@ -85,7 +88,7 @@ func TestItemFilesValid_WhenValid_ExpectTrue(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		res := ItemFilesValid(test.Item, test.Meta)
+		res := ItemFilesValid(OSFS{}, test.Item, test.Meta)
 
 		assert.True(t, res, i)
 	}
@@ -106,7 +109,7 @@ func TestItemFilesValid_WhenInvalid_ExpectFalse(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		res := ItemFilesValid(test.Item, test.Meta)
+		res := ItemFilesValid(OSFS{}, test.Item, test.Meta)
 
 		assert.False(t, res, i)
 	}
@@ -146,3 +149,75 @@ func TestIsExpired(t *testing.T) {
 		assert.Equal(t, test.Expected, expired, i)
 	}
 }
+
+func TestPublishCacheFiles(t *testing.T) {
+	dir := t.TempDir()
+	itemPath := filepath.Join(dir, "item")
+	metaPath := itemPath + MetaSuffix
+
+	require.NoError(t, os.WriteFile(itemPath, []byte("old"), FilesMode))
+	require.NoError(t, os.WriteFile(metaPath, []byte("old meta"), FilesMode))
+
+	itemF, err := os.CreateTemp(TempCacheFilePattern(itemPath))
+	require.NoError(t, err)
+
+	metaF, err := os.CreateTemp(TempCacheFilePattern(metaPath))
+	require.NoError(t, err)
+
+	assert.False(t, strings.HasSuffix(metaF.Name(), MetaSuffix))
+
+	_, _ = itemF.WriteString("new")
+	_, _ = metaF.WriteString("new meta")
+
+	require.NoError(t, itemF.Close())
+	require.NoError(t, metaF.Close())
+
+	err = PublishCacheFiles(OSFS{}, "key", itemF.Name(), itemPath, metaF.Name(), metaPath)
+	require.NoError(t, err)
+
+	item, _ := os.ReadFile(itemPath)
+	meta, _ := os.ReadFile(metaPath)
+
+	assert.Equal(t, "new", string(item))
+	assert.Equal(t, "new meta", string(meta))
+	assert.NoFileExists(t, itemF.Name())
+	assert.NoFileExists(t, metaF.Name())
+}
+
+func TestTempCacheFileTarget(t *testing.T) {
+	tests := []struct {
+		Path     string
+		Expected string
+		OK       bool
+	}{
+		{"dir/.item.123.tmp", "dir/item", true},
+		{"dir/.item--meta.4567.tmp", "dir/item--meta", true},
+		{"dir/.item.v1.89.tmp", "dir/item.v1", true},
+		{"dir/item.123.tmp", "", false},
+		{"dir/.item.abc.tmp", "", false},
+		{"dir/.item.123", "", false},
+		{"dir/..123.tmp", "", false},
+	}
+
+	for i, test := range tests {
+		target, ok := TempCacheFileTarget(FixSeparators(test.Path))
+
+		assert.Equal(t, test.OK, ok, i)
+		assert.Equal(t, FixSeparators(test.Expected), target, i)
+	}
+}
+
+func TestRemoveEmptyDirs(t *testing.T) {
+	root := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b", "c"), DirsMode))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "d", "e"), DirsMode))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "d", "file"), []byte{}, FilesMode))
+
+	RemoveEmptyDirs(OSFS{}, root, filepath.Join(root, "a", "b", "c"), filepath.Join(root, "d", "e"))
+
+	assert.DirExists(t, root)
+	assert.NoDirExists(t, filepath.Join(root, "a"))
+	assert.NoDirExists(t, filepath.Join(root, "d", "e"))
+	assert.FileExists(t, filepath.Join(root, "d", "file"))
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// NewFlightGroup creates a new FlightGroup instance.
+func NewFlightGroup() *FlightGroup {
+	return &FlightGroup{calls: make(map[string]*flightCall)}
+}
+
+// FlightGroup de-duplicates the concurrent calls with the same key.
+type FlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	err  error
+}
+
+// Do executes the fn, making sure only one execution is in-flight for the key at a time.
+// If a duplicate call comes in, it waits for the original one to complete and receives its error.
+// The shared flag is set if the error was given to the multiple callers.
+// The duplicate call stops waiting when its context is done, returning the context's error.
+func (g *FlightGroup) Do(ctx context.Context, key string, fn func() error) (shared bool, err error) {
+	g.mu.Lock()
+
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return true, c.err
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+
+	c := &flightCall{done: make(chan struct{})}
+	g.calls[key] = c
+
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+
+		close(c.done)
+	}()
+
+	c.err = fn()
+
+	return false, c.err
+}
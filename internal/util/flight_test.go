@@ -0,0 +1,93 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightGroup_WhenConcurrent_ExpectSingleExecution(t *testing.T) {
+	const callers = 16
+
+	group := NewFlightGroup()
+	testErr := errors.New("test error")
+
+	var (
+		calls  int32
+		wg     sync.WaitGroup
+		start  = make(chan struct{})
+		shared int32
+	)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			isShared, err := group.Do(context.Background(), "key", func() error {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+
+				return testErr
+			})
+
+			assert.ErrorIs(t, err, testErr)
+
+			if isShared {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(callers-1), atomic.LoadInt32(&shared))
+
+	// The next call is executed again.
+	isShared, err := group.Do(context.Background(), "key", func() error { return nil })
+
+	assert.False(t, isShared)
+	assert.NoError(t, err)
+}
+
+func TestFlightGroup_WhenWaiterContextDone_ExpectContextError(t *testing.T) {
+	group := NewFlightGroup()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderDone := make(chan error)
+
+	go func() {
+		_, err := group.Do(context.Background(), "key", func() error {
+			close(started)
+			<-release
+
+			return nil
+		})
+		leaderDone <- err
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	isShared, err := group.Do(ctx, "key", func() error { return nil })
+
+	assert.True(t, isShared)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(begin), time.Second)
+
+	close(release)
+	assert.NoError(t, <-leaderDone)
+}
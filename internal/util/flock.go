@@ -0,0 +1,97 @@
+package util
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+)
+
+// LocksDir is a name of the dir with the lock files inside the cache dir.
+const LocksDir = ".filecache-locks"
+
+// NewFileLocker creates a new FileLocker instance storing the lock files inside the dir.
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if !fileLockingSupported {
+		return nil, ErrFileLockingUnsupported
+	}
+
+	locksDir := filepath.Join(dir, LocksDir)
+
+	if err := PrepareDir(OSFS{}, locksDir); err != nil {
+		return nil, err
+	}
+
+	return &FileLocker{dir: locksDir}, nil
+}
+
+// FileLocker is an advisory file lock of the keys, shared between the processes.
+// Each key is locked with its own lock file, so the different keys never block each other.
+// The lock file exists only while the key is locked or awaited:
+// the last holder removes it when it's unlocked.
+//
+// The file locks are held by the open file, which is opened on each lock,
+// so the goroutines of the same process are synchronized the same way as the processes.
+type FileLocker struct {
+	dir string
+}
+
+// Lock locks the key's lock file for writing (if exclusive) or for reading.
+// Returns the function to unlock the file.
+func (l *FileLocker) Lock(key string, exclusive bool) (unlock func(), err error) {
+	path := l.getLockPath(key)
+
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, FilesMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file for key %s: %w", key, err)
+		}
+
+		if err := lockFile(f, exclusive); err != nil {
+			_ = f.Close()
+
+			return nil, fmt.Errorf("failed to lock file for key %s: %w", key, err)
+		}
+
+		// The lock file might be removed by the previous holder while the lock has been awaited.
+		if isLockFileOf(f, path) {
+			return func() {
+				releaseLockFile(f, path)
+			}, nil
+		}
+
+		_ = f.Close()
+	}
+}
+
+func (l *FileLocker) getLockPath(key string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	return filepath.Join(l.dir, fmt.Sprintf("%016x", h.Sum64()))
+}
+
+// releaseLockFile unlocks the lock file, removing it if no one else holds or awaits it.
+func releaseLockFile(f *os.File, path string) {
+	if tryLockFile(f) == nil && isLockFileOf(f, path) {
+		_ = os.Remove(path)
+	}
+
+	_ = unlockFile(f)
+	_ = f.Close()
+}
+
+// isLockFileOf returns true if the opened file is still stored by the path.
+func isLockFileOf(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	stored, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(opened, stored)
+}
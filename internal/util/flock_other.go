@@ -0,0 +1,19 @@
+//go:build !unix
+
+package util
+
+import "os"
+
+const fileLockingSupported = false
+
+func lockFile(_ *os.File, _ bool) error {
+	return ErrFileLockingUnsupported
+}
+
+func tryLockFile(_ *os.File) error {
+	return ErrFileLockingUnsupported
+}
+
+func unlockFile(_ *os.File) error {
+	return ErrFileLockingUnsupported
+}
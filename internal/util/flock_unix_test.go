@@ -0,0 +1,140 @@
+//go:build unix
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLocker(t *testing.T) {
+	locker, err := NewFileLocker(t.TempDir())
+	require.NoError(t, err)
+
+	unlock1, err := locker.Lock("key", false)
+	require.NoError(t, err)
+
+	unlock2, err := locker.Lock("key", false)
+	require.NoError(t, err)
+
+	locked := make(chan struct{})
+
+	go func() {
+		unlock, err := locker.Lock("key", true)
+		assert.NoError(t, err)
+
+		close(locked)
+		unlock()
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("exclusive lock acquired while shared locks are held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock1()
+	unlock2()
+
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("exclusive lock not acquired after shared locks are released")
+	}
+}
+
+func TestFileLocker_WhenDifferentKeys_ExpectNotBlocked(t *testing.T) {
+	dir := t.TempDir()
+
+	// The lockers don't share the open lock files, like the lockers in the different processes.
+	locker1, err := NewFileLocker(dir)
+	require.NoError(t, err)
+
+	locker2, err := NewFileLocker(dir)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		unlockA, err := locker1.Lock("a", true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		defer unlockA()
+
+		unlockE, err := locker2.Lock("e", true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		defer unlockE()
+
+		for _, key := range []string{"b72", "d"} {
+			unlock, err := locker1.Lock(key, false)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			unlock()
+
+			unlock, err = locker2.Lock(key, true)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			unlock()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("key is blocked by the other keys")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, LocksDir))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "lock files are removed when unlocked")
+}
+
+func TestFileLocker_WhenLockFileRemovedWhileAwaited_ExpectLocked(t *testing.T) {
+	locker, err := NewFileLocker(t.TempDir())
+	require.NoError(t, err)
+
+	unlock, err := locker.Lock("key", true)
+	require.NoError(t, err)
+
+	locked := make(chan func())
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			unlock, err := locker.Lock("key", true)
+			assert.NoError(t, err)
+
+			locked <- unlock
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	unlock()
+
+	// The waiters are holding the key one by one, though the first holder removes the lock file.
+	unlock = <-locked
+
+	select {
+	case <-locked:
+		t.Fatal("exclusive lock acquired twice")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	(<-locked)()
+}
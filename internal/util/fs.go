@@ -0,0 +1,39 @@
+package util
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// FS is a file system of the cache files, used by the files' helpers.
+type FS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Remove(name string) error
+	Rename(oldName string, newName string) error
+	MkdirAll(name string) error
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// OSFS is the FS of the operating system.
+type OSFS struct{}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFS) Rename(oldName string, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (OSFS) MkdirAll(name string) error {
+	return os.MkdirAll(name, DirsMode)
+}
+
+func (OSFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
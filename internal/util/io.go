@@ -51,3 +51,27 @@ func ReadAll(ctx context.Context, r io.Reader) ([]byte, error) {
 		}
 	}
 }
+
+// NewReadCloser returns the io.ReadCloser reading from the r and closing all the closers in order on Close.
+// Returns the first error returned by the closers.
+func NewReadCloser(r io.Reader, closers ...io.Closer) io.ReadCloser {
+	return &readCloser{Reader: r, closers: closers}
+}
+
+type readCloser struct {
+	io.Reader
+
+	closers []io.Closer
+}
+
+func (rc *readCloser) Close() error {
+	var firstErr error
+
+	for _, c := range rc.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
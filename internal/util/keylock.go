@@ -2,36 +2,99 @@ package util
 
 import "sync"
 
+// NewKeysLocker creates a new KeysLocker instance.
 func NewKeysLocker() *KeysLocker {
 	return &KeysLocker{keys: make(map[string]*KeyLocker)}
 }
 
+// KeysLocker is a per-key readers/writer mutex safe for concurrent use.
+// The key's mutex exists only while the key is locked or awaited,
+// so the memory usage is bounded by the number of concurrently used keys.
 type KeysLocker struct {
+	mu   sync.Mutex
 	keys map[string]*KeyLocker
 }
 
+// Lock locks the key for writing.
 func (k *KeysLocker) Lock(key string) {
-	kl, ok := k.keys[key]
-	if ok {
-		kl.Lock()
+	k.acquire(key).Lock()
+}
+
+// Unlock unlocks the key locked for writing.
+// Like for the sync.RWMutex, it is a run-time error if the key is not locked for writing,
+// unless the key is neither locked nor awaited by anyone: then the call is a no-op.
+func (k *KeysLocker) Unlock(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
 
+	kl, ok := k.keys[key]
+	if !ok {
 		return
 	}
 
-	k.keys[key] = &KeyLocker{}
+	kl.Unlock()
+	k.release(key, kl)
+}
 
-	k.keys[key].Lock()
+// RLock locks the key for reading.
+// Multiple readers may hold the key at the same time, while the writers wait.
+func (k *KeysLocker) RLock(key string) {
+	k.acquire(key).RLock()
 }
 
-func (k *KeysLocker) Unlock(key string) {
+// RUnlock unlocks the key locked for reading.
+// Like for the sync.RWMutex, it is a run-time error if the key is not locked for reading,
+// unless the key is neither locked nor awaited by anyone: then the call is a no-op.
+func (k *KeysLocker) RUnlock(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
 	kl, ok := k.keys[key]
 	if !ok {
 		return
 	}
 
-	kl.Unlock()
+	kl.RUnlock()
+	k.release(key, kl)
+}
+
+// Len returns the number of the currently tracked keys.
+func (k *KeysLocker) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.keys)
 }
 
+// acquire returns the key's mutex, increasing its references counter.
+func (k *KeysLocker) acquire(key string) *KeyLocker {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	kl, ok := k.keys[key]
+	if !ok {
+		kl = &KeyLocker{}
+		k.keys[key] = kl
+	}
+
+	kl.refs++
+
+	return kl
+}
+
+// release decreases the key's mutex references counter
+// and removes it when nobody holds or awaits it. Requires k.mu to be locked.
+func (k *KeysLocker) release(key string, kl *KeyLocker) {
+	kl.refs--
+
+	if kl.refs <= 0 {
+		delete(k.keys, key)
+	}
+}
+
+// KeyLocker is a readers/writer mutex of the single key.
 type KeyLocker struct {
-	sync.Mutex
+	sync.RWMutex
+
+	refs int
 }
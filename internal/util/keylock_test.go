@@ -0,0 +1,126 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysLocker_WhenConcurrent_ExpectExclusiveAccess(t *testing.T) {
+	const (
+		keysCount  = 16
+		goroutines = 64
+		iterations = 200
+	)
+
+	locker := NewKeysLocker()
+	counters := make([]int, keysCount)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < iterations; i++ {
+				idx := (g + i) % keysCount
+				key := fmt.Sprintf("key%d", idx)
+
+				locker.Lock(key)
+				counters[idx]++
+				locker.Unlock(key)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	total := 0
+	for _, c := range counters {
+		total += c
+	}
+
+	assert.Equal(t, goroutines*iterations, total)
+	assert.Equal(t, 0, locker.Len())
+}
+
+func TestKeysLocker_WhenUnlocked_ExpectKeysReleased(t *testing.T) {
+	locker := NewKeysLocker()
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+
+		locker.Lock(key)
+		assert.Equal(t, 1, locker.Len())
+		locker.Unlock(key)
+	}
+
+	assert.Equal(t, 0, locker.Len())
+
+	// Unlocking an unknown key is a no-op.
+	locker.Unlock("unknown")
+
+	assert.Equal(t, 0, locker.Len())
+}
+
+func TestKeysLocker_WhenReadLocked_ExpectReadersParallelAndWriterWaits(t *testing.T) {
+	const readers = 8
+
+	locker := NewKeysLocker()
+
+	var (
+		allLocked sync.WaitGroup
+		release   = make(chan struct{})
+		done      sync.WaitGroup
+	)
+
+	allLocked.Add(readers)
+	done.Add(readers)
+
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer done.Done()
+
+			locker.RLock("key")
+			allLocked.Done()
+
+			<-release
+
+			locker.RUnlock("key")
+		}()
+	}
+
+	// All the readers hold the key at the same time, otherwise this would block forever.
+	allLocked.Wait()
+
+	writerLocked := make(chan struct{})
+
+	go func() {
+		locker.Lock("key")
+		close(writerLocked)
+	}()
+
+	select {
+	case <-writerLocked:
+		t.Fatal("writer acquired the key while readers hold it")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	done.Wait()
+
+	select {
+	case <-writerLocked:
+	case <-time.After(time.Second):
+		t.Fatal("writer didn't acquire the key after readers released it")
+	}
+
+	locker.Unlock("key")
+
+	assert.Equal(t, 0, locker.Len())
+}
@@ -0,0 +1,93 @@
+package filecache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareInvalidateTestCache(t *testing.T) filecache.FileCache {
+	fc, err := filecache.New(getTarget(t, "invalidate"))
+	require.NoError(t, err)
+
+	for _, key := range []string{"user:42:profile", "user:42:orders", "user:420:profile", "user:1:profile"} {
+		_, err := fc.WriteData(
+			context.Background(),
+			key,
+			[]byte(key),
+			filecache.ItemOptions{Fields: filecache.NewValues("kind", key[strings.LastIndex(key, ":")+1:])},
+		)
+		require.NoError(t, err)
+	}
+
+	return fc
+}
+
+func assertHits(t *testing.T, fc filecache.FileCache, expected map[string]bool) {
+	t.Helper()
+
+	for key, expectHit := range expected {
+		res, err := fc.Read(context.Background(), key)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectHit, res.Hit(), key)
+	}
+}
+
+func TestFileCache_InvalidatePrefix(t *testing.T) {
+	fc := prepareInvalidateTestCache(t)
+
+	removed, err := fc.InvalidatePrefix(context.Background(), "user:42:")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	assertHits(t, fc, map[string]bool{
+		"user:42:profile":  false,
+		"user:42:orders":   false,
+		"user:420:profile": true,
+		"user:1:profile":   true,
+	})
+}
+
+func TestFileCache_InvalidateMatch(t *testing.T) {
+	fc := prepareInvalidateTestCache(t)
+
+	removed, err := fc.InvalidateMatch(context.Background(), func(entry filecache.ScanEntry) bool {
+		return entry.Options.Fields["kind"] == "profile"
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	assertHits(t, fc, map[string]bool{
+		"user:42:profile":  false,
+		"user:42:orders":   true,
+		"user:420:profile": false,
+		"user:1:profile":   false,
+	})
+}
+
+func TestFileCache_InvalidateMatch_WhenContextCanceled_ExpectError(t *testing.T) {
+	fc := prepareInvalidateTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n, err := fc.InvalidateMatch(ctx, func(entry filecache.ScanEntry) bool {
+		// The first matched item is removed, then the scan is stopped.
+		cancel()
+
+		return true
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, n)
+
+	_, err = fc.InvalidatePrefix(ctx, "user:")
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
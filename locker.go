@@ -0,0 +1,61 @@
+package filecache
+
+import "github.com/kukymbr/filecache/v2/internal/util"
+
+// newItemsLocker creates a new itemsLocker instance.
+// If the files flag is set, the items are locked across the processes using the lock files inside the dir.
+func newItemsLocker(dir string, files bool) (*itemsLocker, error) {
+	l := &itemsLocker{keys: util.NewKeysLocker()}
+
+	if files {
+		fl, err := util.NewFileLocker(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		l.files = fl
+	}
+
+	return l, nil
+}
+
+// itemsLocker locks the cache items inside the process and, optionally, across the processes.
+type itemsLocker struct {
+	keys  *util.KeysLocker
+	files *util.FileLocker
+}
+
+// lock locks the key exclusively, returns the function to unlock it.
+func (l *itemsLocker) lock(key string) (unlock func(), err error) {
+	return l.acquire(key, true)
+}
+
+// rlock locks the key for reading, returns the function to unlock it.
+func (l *itemsLocker) rlock(key string) (unlock func(), err error) {
+	return l.acquire(key, false)
+}
+
+func (l *itemsLocker) acquire(key string, exclusive bool) (unlock func(), err error) {
+	lock, unlockKey := l.keys.RLock, l.keys.RUnlock
+	if exclusive {
+		lock, unlockKey = l.keys.Lock, l.keys.Unlock
+	}
+
+	lock(key)
+
+	if l.files == nil {
+		return func() { unlockKey(key) }, nil
+	}
+
+	unlockFile, err := l.files.Lock(key, exclusive)
+	if err != nil {
+		unlockKey(key)
+
+		return nil, err
+	}
+
+	return func() {
+		unlockFile()
+		unlockKey(key)
+	}, nil
+}
@@ -0,0 +1,587 @@
+package filecache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// NewMemory creates the FileCache instance keeping the items in memory instead of the files,
+// e.g., to replace the FileCache in tests.
+//
+// The items' TTLs, options, tags and invalidations work as in the FileCache created by the New function.
+// Of the instance options, the DefaultTTL, Codec, MaxSize and MaxItems are used:
+// the codec is only stored in the items' options, the data is kept as is;
+// if the MaxSize or MaxItems is exceeded, the least recently used items are evicted.
+// The other options are ignored. The GetPath function returns an empty string.
+func NewMemory(options ...InstanceOptions) FileCache {
+	opt := InstanceOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	fc := &memoryFileCache{
+		items:      newMemoryStore(opt.MaxSize, opt.MaxItems, 0),
+		ttlDefault: TTLEternal,
+		codec:      opt.Codec,
+		locker:     util.NewKeysLocker(),
+		flights:    util.NewFlightGroup(),
+	}
+
+	if opt.DefaultTTL != 0 {
+		fc.ttlDefault = opt.DefaultTTL
+	}
+
+	return fc
+}
+
+type memoryFileCache struct {
+	items      *memoryStore
+	ttlDefault time.Duration
+	codec      Codec
+
+	// locker locks the keys with the writers in progress.
+	locker  *util.KeysLocker
+	flights *util.FlightGroup
+}
+
+func (fc *memoryFileCache) GetPath() string {
+	return ""
+}
+
+func (fc *memoryFileCache) Write(
+	ctx context.Context,
+	key string,
+	reader io.Reader,
+	options ...ItemOptions,
+) (written int64, err error) {
+	w, err := fc.OpenWriter(ctx, key, options...)
+	if err != nil {
+		return 0, err
+	}
+
+	return copyToWriter(ctx, w, reader)
+}
+
+func (fc *memoryFileCache) WriteData(
+	ctx context.Context,
+	key string,
+	data []byte,
+	options ...ItemOptions,
+) (written int64, err error) {
+	return fc.Write(ctx, key, bytes.NewReader(data), options...)
+}
+
+func (fc *memoryFileCache) OpenWriter(ctx context.Context, key string, options ...ItemOptions) (ItemWriter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	opt := ItemOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	if opt.TTL == 0 {
+		opt.TTL = fc.ttlDefault
+	}
+
+	if opt.Codec == nil {
+		opt.Codec = fc.codec
+	}
+
+	fc.locker.Lock(key)
+
+	return &memoryItemWriter{
+		key:     key,
+		options: opt,
+		items:   fc.items,
+		unlock: func() {
+			fc.locker.Unlock(key)
+		},
+	}, nil
+}
+
+func (fc *memoryFileCache) Open(ctx context.Context, key string) (result *OpenResult, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	item, miss := fc.items.get(key)
+	if item == nil {
+		return &OpenResult{missReason: miss}, nil
+	}
+
+	return item.openResult(), nil
+}
+
+func (fc *memoryFileCache) Read(ctx context.Context, key string) (result *ReadResult, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	item, miss := fc.items.get(key)
+	if item == nil {
+		return &ReadResult{missReason: miss}, nil
+	}
+
+	return &ReadResult{
+		hit:     true,
+		data:    append([]byte{}, item.data...),
+		options: item.cloneOptions(),
+	}, nil
+}
+
+func (fc *memoryFileCache) GetOrWrite(
+	ctx context.Context,
+	key string,
+	loader LoaderFn,
+) (result *OpenResult, err error) {
+	result, err = fc.Open(ctx, key)
+	if err != nil || result.Hit() {
+		return result, err
+	}
+
+	for {
+		shared, err := fc.flights.Do(ctx, key, func() error {
+			// The item might be written by the previous flight, which has finished after the miss above.
+			if item, _ := fc.items.get(key); item != nil {
+				return nil
+			}
+
+			reader, options, err := loader(ctx)
+			if err != nil {
+				return err
+			}
+
+			if closer, ok := reader.(io.Closer); ok {
+				defer func() {
+					_ = closer.Close()
+				}()
+			}
+
+			_, err = fc.Write(ctx, key, reader, options)
+
+			return err
+		})
+
+		// The flight is run with the context of its first caller, see the fileCache.GetOrWrite.
+		if shared && isContextError(err) && ctx.Err() == nil {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return fc.Open(ctx, key)
+	}
+}
+
+func (fc *memoryFileCache) Invalidate(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fc.items.remove(key)
+
+	return nil
+}
+
+func (fc *memoryFileCache) InvalidateTags(ctx context.Context, tags ...string) (removed int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return fc.items.removeMatch(func(item *memoryItem) bool {
+		return hasAnyTag(item.options.Tags, tags)
+	}), nil
+}
+
+func (fc *memoryFileCache) InvalidatePrefix(ctx context.Context, prefix string) (removed int, err error) {
+	return fc.InvalidateMatch(ctx, func(entry ScanEntry) bool {
+		return strings.HasPrefix(entry.Key, prefix)
+	})
+}
+
+func (fc *memoryFileCache) InvalidateMatch(
+	ctx context.Context,
+	match func(entry ScanEntry) bool,
+) (removed int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return fc.items.removeMatch(func(item *memoryItem) bool {
+		return match(item.scanEntry())
+	}), nil
+}
+
+func (fc *memoryFileCache) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fc.items.clear()
+
+	return nil
+}
+
+func (fc *memoryFileCache) Close() error {
+	return nil
+}
+
+// memoryItemWriter is the ItemWriter keeping the item's data in memory until it's committed.
+type memoryItemWriter struct {
+	key     string
+	options ItemOptions
+	items   *memoryStore
+	unlock  func()
+
+	buf  bytes.Buffer
+	done bool
+}
+
+func (w *memoryItemWriter) Write(p []byte) (n int, err error) {
+	if w.done {
+		return 0, ErrWriterClosed
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *memoryItemWriter) Close() error {
+	if w.done {
+		return nil
+	}
+
+	return w.Commit()
+}
+
+func (w *memoryItemWriter) Commit() error {
+	if w.done {
+		return ErrWriterClosed
+	}
+
+	w.done = true
+	defer w.unlock()
+
+	item, err := w.items.newItem(w.key, w.buf.Bytes(), &w.options, time.Now())
+	if err != nil {
+		return err
+	}
+
+	w.items.store(item)
+
+	return nil
+}
+
+func (w *memoryItemWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+
+	w.done = true
+	w.buf = bytes.Buffer{}
+	w.unlock()
+
+	return nil
+}
+
+func (w *memoryItemWriter) Written() int64 {
+	return int64(w.buf.Len())
+}
+
+// memoryStore is the set of the items kept in memory, evicting the least recently used items
+// when the size or number of the items exceeds the limits.
+type memoryStore struct {
+	// maxSize is the maximum total size of the items' data, no limit if 0.
+	maxSize int64
+	// maxItems is the maximum number of the items, no limit if 0.
+	maxItems int
+	// ttl is the maximum time the item is kept, no limit if 0.
+	ttl time.Duration
+
+	mu    sync.Mutex
+	size  int64
+	items map[string]*list.Element
+	// order is the list of the items from the most recently used to the least recently used one.
+	order *list.List
+	// epoch is incremented on every removal, so the items read before it are not put.
+	epoch uint64
+}
+
+func newMemoryStore(maxSize int64, maxItems int, ttl time.Duration) *memoryStore {
+	return &memoryStore{
+		maxSize:  maxSize,
+		maxItems: maxItems,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// memoryItem is the item kept in memory.
+// Only the accessedAt field is modified after the item is created, under the store's mutex.
+type memoryItem struct {
+	key        string
+	data       []byte
+	options    ItemOptions
+	modTime    time.Time
+	accessedAt time.Time
+	touchedAt  time.Time
+	expiresAt  time.Time
+}
+
+// newItem creates the item, storing its fields the same way the file cache does.
+func (t *memoryStore) newItem(
+	key string,
+	data []byte,
+	options *ItemOptions,
+	modTime time.Time,
+) (*memoryItem, error) {
+	now := time.Now()
+	item := &memoryItem{key: key, data: data, modTime: modTime, accessedAt: now, touchedAt: now}
+
+	if options != nil {
+		fields, err := encodeFields(key, options.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		item.options = *options
+		item.options.Fields = fields
+	}
+
+	if item.options.TTL > 0 {
+		item.expiresAt = modTime.Add(item.options.TTL)
+	}
+
+	if t.ttl > 0 {
+		if expiresAt := time.Now().Add(t.ttl); item.expiresAt.IsZero() || expiresAt.Before(item.expiresAt) {
+			item.expiresAt = expiresAt
+		}
+	}
+
+	return item, nil
+}
+
+func (item *memoryItem) isExpired() bool {
+	return !item.expiresAt.IsZero() && time.Now().After(item.expiresAt)
+}
+
+// openResult returns the OpenResult reading the item's data.
+func (item *memoryItem) openResult() *OpenResult {
+	return &OpenResult{
+		hit:     true,
+		reader:  newBytesItemReader(item.data),
+		options: item.cloneOptions(),
+		size:    int64(len(item.data)),
+		modTime: item.modTime,
+	}
+}
+
+// scanEntry returns the ScanEntry describing the item.
+func (item *memoryItem) scanEntry() ScanEntry {
+	return ScanEntry{
+		Key:        item.key,
+		CreatedAt:  item.modTime,
+		AccessedAt: item.accessedAt,
+		Size:       int64(len(item.data)),
+		Options:    item.cloneOptions(),
+	}
+}
+
+// cloneOptions returns the copy of the item's options, so the callers can't modify the kept item.
+func (item *memoryItem) cloneOptions() *ItemOptions {
+	options := item.options
+
+	if options.Tags != nil {
+		options.Tags = append([]string{}, options.Tags...)
+	}
+
+	if options.Fields != nil {
+		options.Fields = make(Values, len(item.options.Fields))
+
+		for k, v := range item.options.Fields {
+			options.Fields[k] = v
+		}
+	}
+
+	return &options
+}
+
+// get returns the valid item by the key.
+// If the item is not found, the nil item and the miss reason are returned.
+func (t *memoryStore) get(key string) (item *memoryItem, miss error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	el, ok := t.items[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	item = el.Value.(*memoryItem)
+
+	if item.isExpired() {
+		t.removeElement(el)
+
+		return nil, ErrExpired
+	}
+
+	item.accessedAt = time.Now()
+	t.order.MoveToFront(el)
+
+	return item, nil
+}
+
+// touch marks the item touched, if it hasn't been touched during the interval.
+// Returns false if the item has been touched recently.
+func (t *memoryStore) touch(item *memoryItem, interval time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Since(item.touchedAt) < interval {
+		return false
+	}
+
+	item.touchedAt = time.Now()
+
+	return true
+}
+
+// currentEpoch returns the epoch to put the items read after it.
+func (t *memoryStore) currentEpoch() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.epoch
+}
+
+// put stores the item, if no items have been removed since the epoch.
+func (t *memoryStore) put(epoch uint64, item *memoryItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if epoch == t.epoch {
+		t.storeLocked(item)
+	}
+}
+
+// store stores the item, replacing the item of the same key.
+func (t *memoryStore) store(item *memoryItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.storeLocked(item)
+}
+
+// storeLocked stores the item, evicting the least recently used items. Requires the mutex to be locked.
+func (t *memoryStore) storeLocked(item *memoryItem) {
+	if el, ok := t.items[item.key]; ok {
+		t.removeElement(el)
+	}
+
+	t.items[item.key] = t.order.PushFront(item)
+	t.size += int64(len(item.data))
+
+	for t.maxSize > 0 && t.size > t.maxSize || t.maxItems > 0 && t.order.Len() > t.maxItems {
+		t.removeElement(t.order.Back())
+	}
+}
+
+// remove removes the items by the keys, returns the number of removed items.
+func (t *memoryStore) remove(keys ...string) (removed int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.epoch++
+
+	for _, key := range keys {
+		if el, ok := t.items[key]; ok {
+			t.removeElement(el)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// removeMatch removes the valid items matching the function, returns the number of removed items.
+// The expired items are removed anyway.
+// The function is called without the mutex locked, so it may use the cache;
+// the items replaced while matching are kept.
+func (t *memoryStore) removeMatch(match func(item *memoryItem) bool) (removed int) {
+	matched := make([]*memoryItem, 0)
+
+	for _, item := range t.snapshot() {
+		if match(item) {
+			matched = append(matched, item)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.epoch++
+
+	for _, item := range matched {
+		if el, ok := t.items[item.key]; ok && el.Value.(*memoryItem) == item {
+			t.removeElement(el)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// snapshot removes the expired items and returns the valid ones.
+func (t *memoryStore) snapshot() []*memoryItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.epoch++
+
+	items := make([]*memoryItem, 0, len(t.items))
+
+	for _, el := range t.items {
+		item := el.Value.(*memoryItem)
+
+		if item.isExpired() {
+			t.removeElement(el)
+
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func (t *memoryStore) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.epoch++
+	t.size = 0
+	t.items = make(map[string]*list.Element)
+	t.order.Init()
+}
+
+// removeElement removes the item's element. Requires the mutex to be locked.
+func (t *memoryStore) removeElement(el *list.Element) {
+	item := t.order.Remove(el).(*memoryItem)
+
+	delete(t.items, item.key)
+	t.size -= int64(len(item.data))
+}
@@ -0,0 +1,321 @@
+package filecache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMemoryAndFileCaches returns the memory and file caches with the same options.
+func newMemoryAndFileCaches(t *testing.T, options filecache.InstanceOptions) map[string]filecache.FileCache {
+	files, err := filecache.New(getTarget(t, "memory"), options)
+	require.NoError(t, err)
+
+	return map[string]filecache.FileCache{
+		"memory": filecache.NewMemory(options),
+		"files":  files,
+	}
+}
+
+func TestMemory_WriteRead(t *testing.T) {
+	caches := newMemoryAndFileCaches(t, filecache.InstanceOptions{DefaultTTL: time.Hour})
+
+	for name, fc := range caches {
+		ctx := context.Background()
+
+		n, err := fc.WriteData(ctx, "key1", []byte("value1"), filecache.ItemOptions{
+			Name:   "Key 1",
+			Tags:   []string{"tag1"},
+			Fields: filecache.NewValues("field1", "val1"),
+		})
+		require.NoError(t, err, name)
+		assert.Equal(t, int64(6), n, name)
+
+		res, err := fc.Read(ctx, "key1")
+		require.NoError(t, err, name)
+		require.True(t, res.Hit(), name)
+		assert.Equal(t, "value1", string(res.Data()), name)
+		assert.Equal(t, "Key 1", res.Options().Name, name)
+		assert.Equal(t, time.Hour, res.Options().TTL, name)
+		assert.Equal(t, []string{"tag1"}, res.Options().Tags, name)
+		assert.Equal(t, "val1", res.Options().Fields["field1"], name)
+		assert.NoError(t, res.MissReason(), name)
+
+		openRes, err := fc.Open(ctx, "key1")
+		require.NoError(t, err, name)
+		require.True(t, openRes.Hit(), name)
+		assert.Equal(t, int64(6), openRes.Size(), name)
+		assert.WithinDuration(t, time.Now(), openRes.ModTime(), time.Minute, name)
+
+		data, err := io.ReadAll(openRes.Reader())
+		require.NoError(t, err, name)
+		require.NoError(t, openRes.Reader().Close(), name)
+		assert.Equal(t, "value1", string(data), name)
+
+		res, err = fc.Read(ctx, "unknown")
+		require.NoError(t, err, name)
+		assert.False(t, res.Hit(), name)
+		assert.ErrorIs(t, res.MissReason(), filecache.ErrNotFound, name)
+	}
+}
+
+func TestMemory_WhenExpired_ExpectMiss(t *testing.T) {
+	caches := newMemoryAndFileCaches(t, filecache.InstanceOptions{DefaultTTL: 50 * time.Millisecond})
+
+	for name, fc := range caches {
+		ctx := context.Background()
+
+		_, err := fc.WriteData(ctx, "default", []byte("value"))
+		require.NoError(t, err, name)
+
+		_, err = fc.WriteData(ctx, "eternal", []byte("value"), filecache.ItemOptions{TTL: filecache.TTLEternal})
+		require.NoError(t, err, name)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	for name, fc := range caches {
+		res, err := fc.Open(context.Background(), "default")
+		require.NoError(t, err, name)
+		assert.False(t, res.Hit(), name)
+		assert.ErrorIs(t, res.MissReason(), filecache.ErrExpired, name)
+
+		res, err = fc.Open(context.Background(), "eternal")
+		require.NoError(t, err, name)
+		require.True(t, res.Hit(), name)
+		require.NoError(t, res.Reader().Close(), name)
+	}
+}
+
+func TestMemory_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		invalidate func(fc filecache.FileCache) (int, error)
+		removed    int
+		kept       []string
+	}{
+		"key": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return 0, fc.Invalidate(ctx, "a1")
+			},
+			kept: []string{"a2", "b1"},
+		},
+		"tags": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return fc.InvalidateTags(ctx, "odd", "unknown")
+			},
+			removed: 2,
+			kept:    []string{"a2"},
+		},
+		"prefix": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return fc.InvalidatePrefix(ctx, "a")
+			},
+			removed: 2,
+			kept:    []string{"b1"},
+		},
+		"match": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return fc.InvalidateMatch(ctx, func(entry filecache.ScanEntry) bool {
+					return entry.Size > 2
+				})
+			},
+			removed: 1,
+			kept:    []string{"a1", "b1"},
+		},
+		"match reading": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return fc.InvalidateMatch(ctx, func(entry filecache.ScanEntry) bool {
+					res, err := fc.Read(ctx, entry.Key)
+
+					return err == nil && string(res.Data()) == "v22"
+				})
+			},
+			removed: 1,
+			kept:    []string{"a1", "b1"},
+		},
+		"clear": {
+			invalidate: func(fc filecache.FileCache) (int, error) {
+				return 0, fc.Clear(ctx)
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		for name, fc := range newMemoryAndFileCaches(t, filecache.InstanceOptions{}) {
+			name = testName + "/" + name
+
+			for key, value := range map[string]string{"a1": "v1", "a2": "v22", "b1": "v3"} {
+				options := filecache.ItemOptions{Tags: []string{"odd"}}
+				if key == "a2" {
+					options.Tags = []string{"even"}
+				}
+
+				_, err := fc.WriteData(ctx, key, []byte(value), options)
+				require.NoError(t, err, name)
+			}
+
+			removed, err := test.invalidate(fc)
+			require.NoError(t, err, name)
+			assert.Equal(t, test.removed, removed, name)
+
+			for _, key := range []string{"a1", "a2", "b1"} {
+				res, err := fc.Read(ctx, key)
+				require.NoError(t, err, name)
+
+				expected := false
+
+				for _, kept := range test.kept {
+					expected = expected || kept == key
+				}
+
+				assert.Equal(t, expected, res.Hit(), name+"/"+key)
+			}
+		}
+	}
+}
+
+func TestMemory_OpenWriter(t *testing.T) {
+	fc := filecache.NewMemory()
+	ctx := context.Background()
+
+	w, err := fc.OpenWriter(ctx, "key")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("value"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), w.Written())
+
+	res, err := fc.Read(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+
+	require.NoError(t, w.Abort())
+
+	res, err = fc.Read(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+
+	w, err = fc.OpenWriter(ctx, "key")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("value"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.Commit(), filecache.ErrWriterClosed)
+
+	_, err = w.Write([]byte("more"))
+	assert.ErrorIs(t, err, filecache.ErrWriterClosed)
+
+	res, err = fc.Read(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	assert.Equal(t, "value", string(res.Data()))
+}
+
+func TestMemory_GetOrWrite(t *testing.T) {
+	fc := filecache.NewMemory()
+	ctx := context.Background()
+
+	var calls atomic.Int32
+
+	loader := func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+
+		return strings.NewReader("loaded"), filecache.ItemOptions{Name: "loaded"}, nil
+	}
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res, err := fc.GetOrWrite(ctx, "key", loader)
+			if !assert.NoError(t, err) || !assert.True(t, res.Hit()) {
+				return
+			}
+
+			data, err := io.ReadAll(res.Reader())
+			assert.NoError(t, err)
+			assert.Equal(t, "loaded", string(data))
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err := fc.GetOrWrite(ctx, "failing", func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+		return nil, filecache.ItemOptions{}, errors.New("test error")
+	})
+	assert.Error(t, err)
+}
+
+func TestMemory_WhenLimitsExceeded_ExpectEvicted(t *testing.T) {
+	fc := filecache.NewMemory(filecache.InstanceOptions{MaxItems: 2})
+	ctx := context.Background()
+
+	for _, key := range []string{"key1", "key2"} {
+		_, err := fc.WriteData(ctx, key, []byte(key))
+		require.NoError(t, err)
+	}
+
+	res, err := fc.Read(ctx, "key1")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+
+	_, err = fc.WriteData(ctx, "key3", []byte("key3"))
+	require.NoError(t, err)
+
+	for key, hit := range map[string]bool{"key1": true, "key2": false, "key3": true} {
+		res, err := fc.Read(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, hit, res.Hit(), key)
+	}
+}
+
+func TestMemory_WhenFields_ExpectStoredAsFileCache(t *testing.T) {
+	ctx := context.Background()
+
+	for name, fc := range newMemoryAndFileCaches(t, filecache.InstanceOptions{}) {
+		fields := filecache.NewValues("n", 1, "s", "str", "list", []int{1, 2})
+
+		_, err := fc.WriteData(ctx, "key", []byte("value"), filecache.ItemOptions{Fields: fields})
+		require.NoError(t, err, name)
+
+		fields["s"] = "changed"
+
+		res, err := fc.Read(ctx, "key")
+		require.NoError(t, err, name)
+		require.True(t, res.Hit(), name)
+
+		assert.Equal(t, filecache.Values{
+			"n":    float64(1),
+			"s":    "str",
+			"list": []any{float64(1), float64(2)},
+		}, res.Options().Fields, name)
+
+		_, err = fc.WriteData(ctx, "invalid", []byte("value"), filecache.ItemOptions{
+			Fields: filecache.NewValues("ch", make(chan int)),
+		})
+		assert.Error(t, err, name)
+
+		res, err = fc.Read(ctx, "invalid")
+		require.NoError(t, err, name)
+		assert.False(t, res.Hit(), name)
+	}
+}
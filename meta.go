@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"time"
 
 	"github.com/kukymbr/filecache/v2/internal/util"
@@ -29,18 +30,98 @@ type meta struct {
 
 	// Fields is a map of any other metadata fields.
 	Fields Values `json:"f,omitempty"`
+
+	// Tags are the tags to invalidate the item by.
+	Tags []string `json:"g,omitempty"`
+
+	// Codec is a name of the Codec the item's data is compressed with.
+	Codec string `json:"z,omitempty"`
+
+	// KeyID is an ID of the encryption key the item's data is encrypted with.
+	KeyID string `json:"x,omitempty"`
+
+	// Size is a length of the item's data before the compression and encryption.
+	Size int64 `json:"l,omitempty"`
+
+	// SumAlgo is a ChecksumAlgorithm of the item's data checksum.
+	SumAlgo string `json:"a,omitempty"`
+
+	// Sum is a hex-encoded checksum of the item's data before the compression and encryption.
+	Sum string `json:"h,omitempty"`
+
+	// FileSize is a size of the item's file the meta is published with.
+	FileSize int64 `json:"s,omitempty"`
+
+	// FileModTime is a modification time of the item's file the meta is published with, in Unix nanoseconds.
+	// Together with the FileSize, it identifies the item's file, so the meta is never paired with another one.
+	FileModTime int64 `json:"m,omitempty"`
+}
+
+// sealedMeta is an envelope of the encrypted data, e.g., the meta stored in the meta file
+// when the encryption is enabled.
+type sealedMeta struct {
+	// KeyID is an ID of the encryption key the data is encrypted with.
+	KeyID string `json:"e"`
+
+	// Data is an encrypted data.
+	Data []byte `json:"d"`
 }
 
 func (m meta) isExpired() bool {
 	return util.IsExpired(m.CreatedAt, m.TTL)
 }
 
-func saveMeta(ctx context.Context, meta *meta, target *os.File) error {
+// stamp stores the identity of the item's file in the meta.
+func (m *meta) stamp(file fs.FileInfo) {
+	m.FileSize = file.Size()
+	m.FileModTime = file.ModTime().UnixNano()
+}
+
+// belongsTo returns false if the meta is published with another item's file.
+// The meta of the items written before the files' identities were stored belongs to any file.
+func (m *meta) belongsTo(file fs.FileInfo) bool {
+	if m.FileModTime == 0 {
+		return true
+	}
+
+	return m.FileSize == file.Size() && m.FileModTime == file.ModTime().UnixNano()
+}
+
+// encodeFields returns the copy of the fields as they are read from the meta file,
+// e.g., with the numbers decoded as float64.
+func encodeFields(key string, fields Values) (Values, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	data, err := easyjson.Marshal(&meta{Fields: fields})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields for key %s: %w", key, err)
+	}
+
+	var m meta
+
+	if err := easyjson.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fields for key %s: %w", key, err)
+	}
+
+	return m.Fields, nil
+}
+
+// saveMeta writes the meta to the target, encrypting it if the crypt is not nil.
+func saveMeta(ctx context.Context, meta *meta, target io.Writer, crypt *encryptor) error {
 	data, err := easyjson.Marshal(meta)
 	if err != nil {
 		return fmt.Errorf("failed to marshal meta for key %s: %w", meta.Key, err)
 	}
 
+	if crypt != nil {
+		data, err = seal(data, crypt)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt meta for key %s: %w", meta.Key, err)
+		}
+	}
+
 	if _, err := util.CopyWithCtx(ctx, target, bytes.NewReader(data)); err != nil {
 		return fmt.Errorf("failed to save meta for key %s: %w", meta.Key, err)
 	}
@@ -48,12 +129,18 @@ func saveMeta(ctx context.Context, meta *meta, target *os.File) error {
 	return nil
 }
 
-func readMeta(key string, path string) (*meta, error) {
-	data, err := os.ReadFile(path)
+// readMeta reads the meta from the file, decrypting it if it's encrypted.
+func readMeta(storage Storage, key string, path string, crypt *encryptor) (*meta, error) {
+	data, err := readFile(storage, path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read meta file for key %s: %w", key, err)
 	}
 
+	data, err = openSealed(data, crypt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt meta for key %s: %w", key, err)
+	}
+
 	var m meta
 
 	if err := easyjson.Unmarshal(data, &m); err != nil {
@@ -63,6 +150,33 @@ func readMeta(key string, path string) (*meta, error) {
 	return &m, nil
 }
 
+// seal encrypts the data and wraps it into the sealedMeta envelope.
+func seal(data []byte, crypt *encryptor) ([]byte, error) {
+	id, sealed, err := crypt.seal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return easyjson.Marshal(&sealedMeta{KeyID: id, Data: sealed})
+}
+
+// openSealed decrypts the data wrapped into the sealedMeta envelope.
+// If the data is not sealed, it's returned as is.
+func openSealed(data []byte, crypt *encryptor) ([]byte, error) {
+	var sealed sealedMeta
+
+	if err := easyjson.Unmarshal(data, &sealed); err != nil || sealed.KeyID == "" {
+		//nolint:nilerr
+		return data, nil
+	}
+
+	if crypt == nil {
+		return nil, ErrEncrypted
+	}
+
+	return crypt.open(sealed.KeyID, sealed.Data)
+}
+
 func newMeta(key string, options *ItemOptions, defaultTTL time.Duration) *meta {
 	ttl := defaultTTL
 
@@ -70,19 +184,30 @@ func newMeta(key string, options *ItemOptions, defaultTTL time.Duration) *meta {
 		ttl = options.TTL
 	}
 
-	return &meta{
+	m := &meta{
 		Key:       key,
 		CreatedAt: time.Now(),
 		Name:      options.Name,
 		TTL:       ttl,
 		Fields:    options.Fields,
+		Tags:      options.Tags,
+	}
+
+	if options.Codec != nil {
+		m.Codec = options.Codec.Name()
 	}
+
+	return m
 }
 
 func metaToOptions(meta *meta) *ItemOptions {
+	codec, _ := getCodec(meta.Codec)
+
 	return &ItemOptions{
 		Name:   meta.Name,
 		TTL:    meta.TTL,
 		Fields: meta.Fields,
+		Tags:   meta.Tags,
+		Codec:  codec,
 	}
 }
@@ -18,7 +18,77 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson66711093DecodeGithubComKukymbrFilecacheV2(in *jlexer.Lexer, out *meta) {
+func easyjson66711093DecodeGithubComKukymbrFilecacheV2(in *jlexer.Lexer, out *sealedMeta) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "e":
+			out.KeyID = string(in.String())
+		case "d":
+			if in.IsNull() {
+				in.Skip()
+				out.Data = nil
+			} else {
+				out.Data = in.Bytes()
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson66711093EncodeGithubComKukymbrFilecacheV2(out *jwriter.Writer, in sealedMeta) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.KeyID != "" {
+		const prefix string = ",\"e\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.KeyID))
+	}
+	if len(in.Data) != 0 {
+		const prefix string = ",\"d\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Base64Bytes(in.Data)
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v sealedMeta) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson66711093EncodeGithubComKukymbrFilecacheV2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *sealedMeta) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson66711093DecodeGithubComKukymbrFilecacheV2(l, v)
+}
+func easyjson66711093DecodeGithubComKukymbrFilecacheV21(in *jlexer.Lexer, out *meta) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -60,19 +130,56 @@ func easyjson66711093DecodeGithubComKukymbrFilecacheV2(in *jlexer.Lexer, out *me
 				for !in.IsDelim('}') {
 					key := string(in.String())
 					in.WantColon()
-					var v1 interface{}
-					if m, ok := v1.(easyjson.Unmarshaler); ok {
+					var v4 interface{}
+					if m, ok := v4.(easyjson.Unmarshaler); ok {
 						m.UnmarshalEasyJSON(in)
-					} else if m, ok := v1.(json.Unmarshaler); ok {
+					} else if m, ok := v4.(json.Unmarshaler); ok {
 						_ = m.UnmarshalJSON(in.Raw())
 					} else {
-						v1 = in.Interface()
+						v4 = in.Interface()
 					}
-					(out.Fields)[key] = v1
+					(out.Fields)[key] = v4
 					in.WantComma()
 				}
 				in.Delim('}')
 			}
+		case "g":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				in.Delim('[')
+				if out.Tags == nil {
+					if !in.IsDelim(']') {
+						out.Tags = make([]string, 0, 4)
+					} else {
+						out.Tags = []string{}
+					}
+				} else {
+					out.Tags = (out.Tags)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v5 string
+					v5 = string(in.String())
+					out.Tags = append(out.Tags, v5)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "z":
+			out.Codec = string(in.String())
+		case "x":
+			out.KeyID = string(in.String())
+		case "l":
+			out.Size = int64(in.Int64())
+		case "a":
+			out.SumAlgo = string(in.String())
+		case "h":
+			out.Sum = string(in.String())
+		case "s":
+			out.FileSize = int64(in.Int64())
+		case "m":
+			out.FileModTime = int64(in.Int64())
 		default:
 			in.SkipRecursive()
 		}
@@ -83,7 +190,7 @@ func easyjson66711093DecodeGithubComKukymbrFilecacheV2(in *jlexer.Lexer, out *me
 		in.Consumed()
 	}
 }
-func easyjson66711093EncodeGithubComKukymbrFilecacheV2(out *jwriter.Writer, in meta) {
+func easyjson66711093EncodeGithubComKukymbrFilecacheV21(out *jwriter.Writer, in meta) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -133,35 +240,124 @@ func easyjson66711093EncodeGithubComKukymbrFilecacheV2(out *jwriter.Writer, in m
 		}
 		{
 			out.RawByte('{')
-			v2First := true
-			for v2Name, v2Value := range in.Fields {
-				if v2First {
-					v2First = false
+			v6First := true
+			for v6Name, v6Value := range in.Fields {
+				if v6First {
+					v6First = false
 				} else {
 					out.RawByte(',')
 				}
-				out.String(string(v2Name))
+				out.String(string(v6Name))
 				out.RawByte(':')
-				if m, ok := v2Value.(easyjson.Marshaler); ok {
+				if m, ok := v6Value.(easyjson.Marshaler); ok {
 					m.MarshalEasyJSON(out)
-				} else if m, ok := v2Value.(json.Marshaler); ok {
+				} else if m, ok := v6Value.(json.Marshaler); ok {
 					out.Raw(m.MarshalJSON())
 				} else {
-					out.Raw(json.Marshal(v2Value))
+					out.Raw(json.Marshal(v6Value))
 				}
 			}
 			out.RawByte('}')
 		}
 	}
+	if len(in.Tags) != 0 {
+		const prefix string = ",\"g\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v7, v8 := range in.Tags {
+				if v7 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v8))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Codec != "" {
+		const prefix string = ",\"z\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Codec))
+	}
+	if in.KeyID != "" {
+		const prefix string = ",\"x\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.KeyID))
+	}
+	if in.Size != 0 {
+		const prefix string = ",\"l\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.Size))
+	}
+	if in.SumAlgo != "" {
+		const prefix string = ",\"a\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.SumAlgo))
+	}
+	if in.Sum != "" {
+		const prefix string = ",\"h\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Sum))
+	}
+	if in.FileSize != 0 {
+		const prefix string = ",\"s\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.FileSize))
+	}
+	if in.FileModTime != 0 {
+		const prefix string = ",\"m\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int64(int64(in.FileModTime))
+	}
 	out.RawByte('}')
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v meta) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson66711093EncodeGithubComKukymbrFilecacheV2(w, v)
+	easyjson66711093EncodeGithubComKukymbrFilecacheV21(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *meta) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson66711093DecodeGithubComKukymbrFilecacheV2(l, v)
+	easyjson66711093DecodeGithubComKukymbrFilecacheV21(l, v)
 }
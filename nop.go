@@ -5,38 +5,86 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 )
 
-// NewNop creates no-operation file cache instance.
-func NewNop() FileCache {
-	return &nopFileCache{}
+// NopOptions are the no-operation FileCache options.
+type NopOptions struct {
+	// AlwaysMiss makes the Open and Read functions report the cache miss with the ErrNotFound reason,
+	// so the instance behaves like an always empty cache.
+	// By default, they report the cache hit with the empty data.
+	AlwaysMiss bool
+
+	// Recorder records the calls of the instance's functions, if set.
+	Recorder *NopRecorder
+}
+
+// NewNop creates no-operation file cache instance: nothing is written, and nothing is removed.
+//
+// By default, the Open and Read functions report the cache hit with the empty data.
+// To disable the caching, use the AlwaysMiss option: the data is always missing,
+// and the GetOrWrite function returns the data of the loader, calling it every time.
+func NewNop(options ...NopOptions) FileCache {
+	opt := NopOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	return &nopFileCache{
+		miss:     opt.AlwaysMiss,
+		recorder: opt.Recorder,
+	}
 }
 
-type nopFileCache struct{}
+type nopFileCache struct {
+	miss     bool
+	recorder *NopRecorder
+}
 
 func (fc *nopFileCache) GetPath() string {
 	return os.TempDir()
 }
 
-func (fc *nopFileCache) Write(_ context.Context, _ string, _ io.Reader, _ ...ItemOptions) (written int64, err error) {
+func (fc *nopFileCache) Write(_ context.Context, key string, _ io.Reader, options ...ItemOptions) (int64, error) {
+	fc.recorder.record(NopCall{Method: "Write", Key: key, Options: firstItemOptions(options)})
+
 	return 0, nil
 }
 
-func (fc *nopFileCache) WriteData(_ context.Context, _ string, _ []byte, _ ...ItemOptions) (written int64, err error) {
+func (fc *nopFileCache) WriteData(_ context.Context, key string, _ []byte, options ...ItemOptions) (int64, error) {
+	fc.recorder.record(NopCall{Method: "WriteData", Key: key, Options: firstItemOptions(options)})
+
 	return 0, nil
 }
 
-func (fc *nopFileCache) Open(_ context.Context, _ string) (result *OpenResult, err error) {
+func (fc *nopFileCache) OpenWriter(_ context.Context, key string, options ...ItemOptions) (w ItemWriter, err error) {
+	fc.recorder.record(NopCall{Method: "OpenWriter", Key: key, Options: firstItemOptions(options)})
+
+	return &nopItemWriter{}, nil
+}
+
+func (fc *nopFileCache) Open(_ context.Context, key string) (result *OpenResult, err error) {
+	fc.recorder.record(NopCall{Method: "Open", Key: key})
+
+	if fc.miss {
+		return &OpenResult{missReason: ErrNotFound}, nil
+	}
+
 	return &OpenResult{
-		hit: true,
-		reader: io.NopCloser(
-			strings.NewReader(""),
-		),
+		hit:     true,
+		reader:  newStreamItemReader(io.NopCloser(strings.NewReader("")), 0),
 		options: &ItemOptions{},
 	}, nil
 }
 
-func (fc *nopFileCache) Read(_ context.Context, _ string) (result *ReadResult, err error) {
+func (fc *nopFileCache) Read(_ context.Context, key string) (result *ReadResult, err error) {
+	fc.recorder.record(NopCall{Method: "Read", Key: key})
+
+	if fc.miss {
+		return &ReadResult{missReason: ErrNotFound}, nil
+	}
+
 	return &ReadResult{
 		hit:     true,
 		data:    []byte(""),
@@ -44,10 +92,162 @@ func (fc *nopFileCache) Read(_ context.Context, _ string) (result *ReadResult, e
 	}, nil
 }
 
-func (fc *nopFileCache) Invalidate(_ context.Context, _ string) error {
+func (fc *nopFileCache) GetOrWrite(ctx context.Context, key string, loader LoaderFn) (result *OpenResult, err error) {
+	fc.recorder.record(NopCall{Method: "GetOrWrite", Key: key})
+
+	reader, options, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	readCloser, ok := reader.(io.ReadCloser)
+	if !ok {
+		readCloser = io.NopCloser(reader)
+	}
+
+	return &OpenResult{
+		hit:     true,
+		reader:  newStreamItemReader(readCloser, -1),
+		options: &options,
+		size:    -1,
+	}, nil
+}
+
+func (fc *nopFileCache) Invalidate(_ context.Context, key string) error {
+	fc.recorder.record(NopCall{Method: "Invalidate", Key: key})
+
+	return nil
+}
+
+func (fc *nopFileCache) InvalidateTags(_ context.Context, tags ...string) (removed int, err error) {
+	fc.recorder.record(NopCall{Method: "InvalidateTags", Tags: append([]string{}, tags...)})
+
+	return 0, nil
+}
+
+func (fc *nopFileCache) InvalidatePrefix(_ context.Context, prefix string) (removed int, err error) {
+	fc.recorder.record(NopCall{Method: "InvalidatePrefix", Key: prefix})
+
+	return 0, nil
+}
+
+func (fc *nopFileCache) InvalidateMatch(_ context.Context, _ func(entry ScanEntry) bool) (removed int, err error) {
+	fc.recorder.record(NopCall{Method: "InvalidateMatch"})
+
+	return 0, nil
+}
+
+func (fc *nopFileCache) Clear(_ context.Context) error {
+	fc.recorder.record(NopCall{Method: "Clear"})
+
 	return nil
 }
 
 func (fc *nopFileCache) Close() error {
+	fc.recorder.record(NopCall{Method: "Close"})
+
 	return nil
 }
+
+// NopCall is a call of the no-operation FileCache's function, recorded by the NopRecorder.
+type NopCall struct {
+	// Method is the name of the called function, e.g. "Open".
+	Method string
+
+	// Key is the key of the item, or the prefix of the InvalidatePrefix call.
+	Key string
+
+	// Tags are the tags of the InvalidateTags call.
+	Tags []string
+
+	// Options are the item options of the write calls, nil if not passed.
+	Options *ItemOptions
+}
+
+// NopRecorder records the calls of the no-operation FileCache, see the NopOptions.
+// The zero NopRecorder is ready to use. The NopRecorder is safe for concurrent use.
+type NopRecorder struct {
+	mu    sync.Mutex
+	calls []NopCall
+}
+
+// Calls returns the recorded calls in the order they were made.
+func (r *NopRecorder) Calls() []NopCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]NopCall{}, r.calls...)
+}
+
+// Reset removes the recorded calls.
+func (r *NopRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+func (r *NopRecorder) record(call NopCall) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+// firstItemOptions returns the copy of the first item options, or nil if there are none.
+func firstItemOptions(options []ItemOptions) *ItemOptions {
+	if len(options) == 0 {
+		return nil
+	}
+
+	opt := options[0]
+
+	return &opt
+}
+
+type nopItemWriter struct {
+	written int64
+	done    bool
+}
+
+func (w *nopItemWriter) Write(p []byte) (n int, err error) {
+	if w.done {
+		return 0, ErrWriterClosed
+	}
+
+	w.written += int64(len(p))
+
+	return len(p), nil
+}
+
+func (w *nopItemWriter) Close() error {
+	if w.done {
+		return nil
+	}
+
+	return w.Commit()
+}
+
+func (w *nopItemWriter) Commit() error {
+	if w.done {
+		return ErrWriterClosed
+	}
+
+	w.done = true
+
+	return nil
+}
+
+func (w *nopItemWriter) Abort() error {
+	w.done = true
+
+	return nil
+}
+
+func (w *nopItemWriter) Written() int64 {
+	return w.written
+}
@@ -2,11 +2,13 @@ package filecache_test
 
 import (
 	"context"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/kukymbr/filecache/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNopFileCache(t *testing.T) {
@@ -40,15 +42,120 @@ func TestNopFileCache(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	{
+		res, err := fc.GetOrWrite(context.Background(), "test", func(ctx context.Context) (io.Reader, filecache.ItemOptions, error) {
+			return strings.NewReader("value"), filecache.ItemOptions{Name: "Name"}, nil
+		})
+
+		require.NoError(t, err)
+
+		data, _ := io.ReadAll(res.Reader())
+
+		assert.Equal(t, "value", string(data))
+		assert.Equal(t, "Name", res.Options().Name)
+	}
+
 	{
 		err := fc.Invalidate(context.Background(), "test")
 
 		assert.NoError(t, err)
 	}
 
+	{
+		err := fc.Clear(context.Background())
+
+		assert.NoError(t, err)
+	}
+
 	{
 		path := fc.GetPath()
 
 		assert.NotEmpty(t, path)
 	}
 }
+
+func TestNopFileCache_WhenAlwaysMiss_ExpectMiss(t *testing.T) {
+	fc := filecache.NewNop(filecache.NopOptions{AlwaysMiss: true})
+	ctx := context.Background()
+
+	_, err := fc.WriteData(ctx, "test", []byte("test"))
+	require.NoError(t, err)
+
+	openRes, err := fc.Open(ctx, "test")
+	require.NoError(t, err)
+	assert.False(t, openRes.Hit())
+	assert.ErrorIs(t, openRes.MissReason(), filecache.ErrNotFound)
+
+	readRes, err := fc.Read(ctx, "test")
+	require.NoError(t, err)
+	assert.False(t, readRes.Hit())
+	assert.ErrorIs(t, readRes.MissReason(), filecache.ErrNotFound)
+
+	calls := 0
+
+	for i := 0; i < 2; i++ {
+		res, err := fc.GetOrWrite(ctx, "test", func(ctx context.Context) (io.Reader, filecache.ItemOptions, error) {
+			calls++
+
+			return strings.NewReader("value"), filecache.ItemOptions{}, nil
+		})
+		require.NoError(t, err)
+		require.True(t, res.Hit())
+
+		data, err := io.ReadAll(res.Reader())
+		require.NoError(t, err)
+		assert.Equal(t, "value", string(data))
+	}
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestNopFileCache_WhenRecorder_ExpectCallsRecorded(t *testing.T) {
+	recorder := &filecache.NopRecorder{}
+	fc := filecache.NewNop(filecache.NopOptions{Recorder: recorder})
+	ctx := context.Background()
+
+	_, _ = fc.Write(ctx, "key1", strings.NewReader("value"), filecache.ItemOptions{Name: "Name"})
+	_, _ = fc.Read(ctx, "key1")
+	_, _ = fc.InvalidateTags(ctx, "tag1", "tag2")
+	_, _ = fc.InvalidatePrefix(ctx, "key")
+	_ = fc.Invalidate(ctx, "key2")
+
+	assert.Equal(t, []filecache.NopCall{
+		{Method: "Write", Key: "key1", Options: &filecache.ItemOptions{Name: "Name"}},
+		{Method: "Read", Key: "key1"},
+		{Method: "InvalidateTags", Tags: []string{"tag1", "tag2"}},
+		{Method: "InvalidatePrefix", Key: "key"},
+		{Method: "Invalidate", Key: "key2"},
+	}, recorder.Calls())
+
+	recorder.Reset()
+
+	assert.Empty(t, recorder.Calls())
+}
+
+func TestNopFileCache_WhenWriterDone_ExpectErrWriterClosed(t *testing.T) {
+	fc := filecache.NewNop()
+
+	w, err := fc.OpenWriter(context.Background(), "test")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("value"))
+	require.NoError(t, err)
+	require.NoError(t, w.Commit())
+
+	_, err = w.Write([]byte("value"))
+	assert.ErrorIs(t, err, filecache.ErrWriterClosed)
+	assert.ErrorIs(t, w.Commit(), filecache.ErrWriterClosed)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Abort())
+	assert.Equal(t, int64(5), w.Written())
+
+	w, err = fc.OpenWriter(context.Background(), "test")
+	require.NoError(t, err)
+	require.NoError(t, w.Abort())
+
+	_, err = w.Write([]byte("value"))
+	assert.ErrorIs(t, err, filecache.ErrWriterClosed)
+	assert.ErrorIs(t, w.Commit(), filecache.ErrWriterClosed)
+}
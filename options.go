@@ -23,9 +23,55 @@ type InstanceOptions struct {
 	// May be initialized with any GarbageCollector instance or using one of the predefined GC constructors:
 	//   - NewNopGarbageCollector: the GarbageCollector doing nothing;
 	//   - NewProbabilityGarbageCollector: the GarbageCollector running with the defined probability;
-	//   - NewIntervalGarbageCollector: the GarbageCollector running by the interval.
+	//   - NewIntervalGarbageCollector: the GarbageCollector running by the interval;
+	//   - NewLRUGarbageCollector: the GarbageCollector evicting the least recently used items.
 	GC GarbageCollector
 
+	// MaxSize is the maximum total size of the cache items' data in bytes.
+	// If MaxSize or MaxItems is set, the LRU GarbageCollector is used,
+	// so these options can't be combined with the GC option.
+	MaxSize int64
+
+	// MaxItems is the maximum number of the cache items.
+	// If MaxSize or MaxItems is set, the LRU GarbageCollector is used,
+	// so these options can't be combined with the GC option.
+	MaxItems int
+
+	// FileLocking enables the advisory file locks of the cache items (flock on the unix systems),
+	// so the writes, invalidations and GC deletions of the instances in different processes
+	// sharing the same dir never interleave on the same key.
+	//
+	// The lock files are stored in the dir's .filecache-locks subdirectory.
+	// All the processes sharing the dir must enable this option and use the same PathGenerator.
+	// Not supported on the non-unix systems: the New function returns an error.
+	FileLocking bool
+
+	// Codec is a compression Codec of the items' data.
+	// Items are compressed on write and decompressed on read transparently.
+	// May be overridden by the item's options. No compression by default.
+	//
+	// There is a built-in gzip codec, created by the NewGzipCodec function;
+	// any other compression algorithm might be plugged in by implementing the Codec interface.
+	Codec Codec
+
+	// Encryption is a KeyProvider to encrypt the items' data and metadata with AES-GCM.
+	// The item's data is encrypted in the streaming chunks, the ID of the key is stored with the item,
+	// so the keys might be rotated. No encryption by default.
+	//
+	// The tags index entries are encrypted as well, but the file names are not:
+	// use the hashing PathGenerator to avoid storing the keys in the file names.
+	Encryption KeyProvider
+
+	// Storage is a file system storing the cache files, the OS file system by default (see the NewOSStorage).
+	// The FileLocking option requires the OS storage.
+	Storage Storage
+
+	// Checksum is an algorithm of the items' data checksums, ChecksumCRC32C by default.
+	// The checksum and the length of the data are stored with the item on write
+	// and verified on read: the corrupted items are removed, and the reading fails with the ErrCorrupted error.
+	// Use the ChecksumNone to disable the verification.
+	Checksum ChecksumAlgorithm
+
 	// GCDivisor is a garbage collector run probability divisor
 	// (e.g., 100 is 1/100 probability).
 	//
@@ -43,4 +89,11 @@ type ItemOptions struct {
 
 	// Fields is a map of any other metadata fields.
 	Fields Values
+
+	// Tags are the tags to invalidate the item by using the FileCache's InvalidateTags function.
+	Tags []string
+
+	// Codec is a compression Codec of the item's data.
+	// If not set, the instance's Codec is used.
+	Codec Codec
 }
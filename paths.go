@@ -29,6 +29,8 @@ func WithExt(fn PathGeneratorFn, ext string) PathGeneratorFn {
 }
 
 // FilteredKeyPath uses a key without path separators as a path.
+// Different keys might be mapped to the same path (e.g., "a/b" and "ab"),
+// the item of one of them is a miss with the ErrKeyMismatch reason for another one.
 func FilteredKeyPath(key string) string {
 	path := util.FilterPathIdent(key)
 
@@ -0,0 +1,232 @@
+package filecache
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ItemReader is a reader of the cached item's data, returned by the OpenResult's Reader function.
+//
+// The reader supports seeking and random access reading, so it might be passed to the http.ServeContent.
+// The data of the compressed or encrypted items is decoded sequentially, so their seeking is virtual:
+// the data is decoded from the start and skipped up to the requested position on read,
+// which makes seeking backward expensive.
+// The ReadAt function is safe for concurrent use, while the other functions are not.
+type ItemReader interface {
+	io.ReadCloser
+	io.Seeker
+	io.ReaderAt
+}
+
+const (
+	// itemSkipLimit is a distance the plain item's reader skips forward by reading instead of reopening.
+	itemSkipLimit = 64 * 1024
+)
+
+var (
+	errNotReopenable   = errors.New("item reader can't seek backward")
+	errInvalidWhence   = errors.New("invalid whence")
+	errNegativeSeekPos = errors.New("negative position")
+)
+
+// itemOpener opens the reader of the item's decoded data at the offset.
+// Returns the actual position of the reader, which might be before the offset: the rest is skipped by reading.
+type itemOpener func(off int64) (r io.ReadCloser, pos int64, err error)
+
+// newItemReader creates the ItemReader reading the item's data by the readers of the opener.
+// Function arguments:
+//   - open: the item's data readers opener;
+//   - first: the reader opened at the start of the data;
+//   - size: the item's data size, -1 if unknown;
+//   - seekable: true, if the opener opens the readers exactly at the offset;
+//   - closer: the closer of the item's resources, closed on Close after the readers.
+func newItemReader(open itemOpener, first io.ReadCloser, size int64, seekable bool, closer io.Closer) *itemReader {
+	return &itemReader{
+		open:     open,
+		size:     size,
+		seekable: seekable,
+		closer:   closer,
+		main:     itemStream{r: first},
+	}
+}
+
+// newStreamItemReader creates the ItemReader of the data which can't be reopened,
+// so it only seeks forward.
+func newStreamItemReader(r io.ReadCloser, size int64) *itemReader {
+	return newItemReader(func(int64) (io.ReadCloser, int64, error) {
+		return nil, 0, errNotReopenable
+	}, r, size, false, nil)
+}
+
+// newBytesItemReader creates the ItemReader of the data kept in memory.
+func newBytesItemReader(data []byte) *itemReader {
+	open := func(off int64) (io.ReadCloser, int64, error) {
+		if off > int64(len(data)) {
+			off = int64(len(data))
+		}
+
+		return io.NopCloser(bytes.NewReader(data[off:])), off, nil
+	}
+
+	first, _, _ := open(0)
+
+	return newItemReader(open, first, int64(len(data)), true, nil)
+}
+
+type itemReader struct {
+	open     itemOpener
+	size     int64
+	seekable bool
+	closer   io.Closer
+
+	// main is the stream of the Read function, pos is the virtual position of the reader.
+	main itemStream
+	pos  int64
+
+	// at is the stream of the ReadAt function.
+	atMu sync.Mutex
+	at   itemStream
+}
+
+func (r *itemReader) Read(p []byte) (n int, err error) {
+	n, err = r.read(&r.main, p, r.pos)
+	r.pos += int64(n)
+
+	return n, err
+}
+
+func (r *itemReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errNegativeSeekPos
+	}
+
+	r.atMu.Lock()
+	defer r.atMu.Unlock()
+
+	for n < len(p) && err == nil {
+		var read int
+
+		read, err = r.read(&r.at, p[n:], off+int64(n))
+		n += read
+	}
+
+	if n == len(p) {
+		return n, nil
+	}
+
+	return n, err
+}
+
+func (r *itemReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		size, err := r.dataSize()
+		if err != nil {
+			return 0, err
+		}
+
+		offset += size
+	default:
+		return 0, errInvalidWhence
+	}
+
+	if offset < 0 {
+		return 0, errNegativeSeekPos
+	}
+
+	// The stream is repositioned lazily on the next read.
+	r.pos = offset
+
+	return offset, nil
+}
+
+func (r *itemReader) Close() error {
+	var firstErr error
+
+	for _, c := range []io.Closer{r.main.r, r.at.r, r.closer} {
+		if c == nil {
+			continue
+		}
+
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// dataSize returns the size of the item's data, decoding the data to count it if it's unknown.
+func (r *itemReader) dataSize() (int64, error) {
+	if r.size >= 0 {
+		return r.size, nil
+	}
+
+	rc, pos, err := r.open(0)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	n, err := io.Copy(io.Discard, rc)
+	if err != nil {
+		return 0, err
+	}
+
+	r.size = pos + n
+
+	return r.size, nil
+}
+
+// read reads the stream at the offset, repositioning the stream if needed.
+func (r *itemReader) read(s *itemStream, p []byte, off int64) (n int, err error) {
+	if s.r == nil || off < s.pos || r.seekable && off-s.pos > itemSkipLimit {
+		if err := s.reopen(r.open, off); err != nil {
+			return 0, err
+		}
+	}
+
+	if off > s.pos {
+		skipped, err := io.CopyN(io.Discard, s.r, off-s.pos)
+		s.pos += skipped
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = s.r.Read(p)
+	s.pos += int64(n)
+
+	return n, err
+}
+
+// itemStream is a sequential reader of the item's data with its position.
+type itemStream struct {
+	r   io.ReadCloser
+	pos int64
+}
+
+func (s *itemStream) reopen(open itemOpener, off int64) error {
+	r, pos, err := open(off)
+	if err != nil {
+		return err
+	}
+
+	if s.r != nil {
+		_ = s.r.Close()
+	}
+
+	s.r = r
+	s.pos = pos
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package filecache_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenResult_Reader_WhenSeeking_ExpectData(t *testing.T) {
+	value := make([]byte, 300*1024+17)
+
+	_, err := rand.Read(value)
+	require.NoError(t, err)
+
+	tests := map[string]filecache.InstanceOptions{
+		"plain": {},
+		"gzip":  {Codec: filecache.NewGzipCodec(gzip.BestSpeed)},
+		"encrypted": {
+			Codec:      filecache.NewGzipCodec(gzip.BestSpeed),
+			Encryption: filecache.NewStaticKeyProvider("k1", map[string][]byte{"k1": testKey1}),
+		},
+	}
+
+	for name, options := range tests {
+		fc, err := filecache.New(getTarget(t, "reader"), options)
+		require.NoError(t, err)
+
+		before := time.Now()
+
+		_, err = fc.WriteData(context.Background(), "test", value)
+		require.NoError(t, err)
+
+		res, err := fc.Open(context.Background(), "test")
+		require.NoError(t, err)
+		require.True(t, res.Hit())
+
+		assert.Equal(t, int64(len(value)), res.Size(), name)
+		assert.False(t, res.ModTime().Before(before), name)
+
+		r := res.Reader()
+
+		assertSeekRead(t, r, int64(len(value)), io.SeekEnd, 0, []byte{}, name)
+		assertSeekRead(t, r, 200*1024, io.SeekStart, 200*1024, value[200*1024:200*1024+100], name)
+		assertSeekRead(t, r, 10, io.SeekStart, 10, value[10:110], name)
+		assertSeekRead(t, r, 210, io.SeekCurrent, 100, value[210:310], name)
+		assertSeekRead(t, r, int64(len(value)-5), io.SeekEnd, -5, value[len(value)-5:], name)
+
+		_, err = r.Seek(-1, io.SeekStart)
+		assert.Error(t, err, name)
+
+		var wg sync.WaitGroup
+
+		for _, off := range []int{0, 5, 70 * 1024, 299 * 1024, 3, len(value) - 100} {
+			wg.Add(1)
+
+			go func(off int) {
+				defer wg.Done()
+
+				buf := make([]byte, 100)
+
+				n, err := r.ReadAt(buf, int64(off))
+
+				assert.NoError(t, err, name)
+				assert.Equal(t, 100, n, name)
+				assert.Equal(t, value[off:off+100], buf, name)
+			}(off)
+		}
+
+		wg.Wait()
+
+		n, err := r.ReadAt(make([]byte, 100), int64(len(value)-10))
+		assert.Equal(t, 10, n, name)
+		assert.ErrorIs(t, err, io.EOF, name)
+
+		_, err = r.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(value, data), name)
+
+		assert.NoError(t, r.Close(), name)
+	}
+}
+
+func assertSeekRead(
+	t *testing.T,
+	r filecache.ItemReader,
+	expectedPos int64,
+	whence int,
+	offset int64,
+	expectedData []byte,
+	msg string,
+) {
+	t.Helper()
+
+	pos, err := r.Seek(offset, whence)
+	require.NoError(t, err, msg)
+	assert.Equal(t, expectedPos, pos, msg)
+
+	buf := make([]byte, len(expectedData))
+
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err, msg)
+	assert.Equal(t, expectedData, buf, msg)
+}
@@ -0,0 +1,225 @@
+package filecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// ReadOnlyError is returned by the functions of the read-only FileCache modifying the cache, see the NewReadOnly.
+type ReadOnlyError struct {
+	// Op is the name of the rejected function, e.g. "Write".
+	Op string
+
+	// Key is the key of the item, or the prefix of the InvalidatePrefix call; empty if not applicable.
+	Key string
+}
+
+func (e *ReadOnlyError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("cache is read-only: %s is not supported", e.Op)
+	}
+
+	return fmt.Sprintf("cache is read-only: %s is not supported for key %s", e.Op, e.Key)
+}
+
+// NewReadOnly creates the read-only FileCache instance reading the items from the fsys,
+// e.g., the cache shipped inside the binary with the embed.FS.
+//
+// The items are expected at the fsys root, as they are written by the FileCache created by the New function
+// with the same PathGenerator; use the fs.Sub to read the cache from the fsys subdirectory.
+// Note the embed directive skips the files with names starting with "." or "_" unless the "all:" prefix is used.
+//
+// The items' TTLs are honoured: the expired items are missed, but not removed.
+// The Write, WriteData, OpenWriter, Invalidate*, Clear and, on a cache miss, GetOrWrite functions
+// return the *ReadOnlyError error.
+// Of the instance options, the PathGenerator, Codec and Encryption are used, the other options are ignored.
+// The GetPath function returns the "." path of the fsys root.
+func NewReadOnly(fsys fs.FS, options ...InstanceOptions) FileCache {
+	opt := InstanceOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	fc := &fileCache{
+		dir:           ".",
+		ttlDefault:    TTLEternal,
+		pathGenerator: HashedKeySplitPath,
+		flights:       util.NewFlightGroup(),
+	}
+
+	// The options below are only used to write and remove the items, so they are not validated.
+	// Without them, the init never fails.
+	_ = fc.init(InstanceOptions{
+		PathGenerator: opt.PathGenerator,
+		GC:            NewNopGarbageCollector(),
+		Codec:         opt.Codec,
+		Encryption:    opt.Encryption,
+		Storage:       readOnlyStorage{fsys: fsys},
+		Checksum:      ChecksumNone,
+	})
+
+	return &readOnlyFileCache{fc: fc}
+}
+
+type readOnlyFileCache struct {
+	fc *fileCache
+}
+
+func (fc *readOnlyFileCache) GetPath() string {
+	return fc.fc.GetPath()
+}
+
+func (fc *readOnlyFileCache) Write(_ context.Context, key string, _ io.Reader, _ ...ItemOptions) (int64, error) {
+	return 0, &ReadOnlyError{Op: "Write", Key: key}
+}
+
+func (fc *readOnlyFileCache) WriteData(_ context.Context, key string, _ []byte, _ ...ItemOptions) (int64, error) {
+	return 0, &ReadOnlyError{Op: "WriteData", Key: key}
+}
+
+func (fc *readOnlyFileCache) OpenWriter(_ context.Context, key string, _ ...ItemOptions) (ItemWriter, error) {
+	return nil, &ReadOnlyError{Op: "OpenWriter", Key: key}
+}
+
+func (fc *readOnlyFileCache) Open(ctx context.Context, key string) (result *OpenResult, err error) {
+	return fc.fc.Open(ctx, key)
+}
+
+func (fc *readOnlyFileCache) Read(ctx context.Context, key string) (result *ReadResult, err error) {
+	return fc.fc.Read(ctx, key)
+}
+
+func (fc *readOnlyFileCache) GetOrWrite(ctx context.Context, key string, _ LoaderFn) (result *OpenResult, err error) {
+	result, err = fc.fc.Open(ctx, key)
+	if err != nil || result.Hit() {
+		return result, err
+	}
+
+	return nil, &ReadOnlyError{Op: "GetOrWrite", Key: key}
+}
+
+func (fc *readOnlyFileCache) Invalidate(_ context.Context, key string) error {
+	return &ReadOnlyError{Op: "Invalidate", Key: key}
+}
+
+func (fc *readOnlyFileCache) InvalidateTags(_ context.Context, _ ...string) (removed int, err error) {
+	return 0, &ReadOnlyError{Op: "InvalidateTags"}
+}
+
+func (fc *readOnlyFileCache) InvalidatePrefix(_ context.Context, prefix string) (removed int, err error) {
+	return 0, &ReadOnlyError{Op: "InvalidatePrefix", Key: prefix}
+}
+
+func (fc *readOnlyFileCache) InvalidateMatch(_ context.Context, _ func(entry ScanEntry) bool) (int, error) {
+	return 0, &ReadOnlyError{Op: "InvalidateMatch"}
+}
+
+func (fc *readOnlyFileCache) Clear(_ context.Context) error {
+	return &ReadOnlyError{Op: "Clear"}
+}
+
+func (fc *readOnlyFileCache) Close() error {
+	return fc.fc.Close()
+}
+
+// readOnlyStorage is the Storage reading the files from the fs.FS.
+// The paths are converted to the slash-separated ones of the fs.FS,
+// the functions modifying the files fail with the fs.ErrPermission error.
+type readOnlyStorage struct {
+	fsys fs.FS
+}
+
+func (s readOnlyStorage) CreateTemp(dir string, _ string) (StorageFile, error) {
+	return nil, &fs.PathError{Op: "createtemp", Path: dir, Err: fs.ErrPermission}
+}
+
+func (s readOnlyStorage) Open(name string) (StorageFile, error) {
+	f, err := s.fsys.Open(filepath.ToSlash(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &readOnlyFile{File: f, name: name}, nil
+}
+
+func (s readOnlyStorage) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(s.fsys, filepath.ToSlash(name))
+}
+
+func (s readOnlyStorage) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrPermission}
+}
+
+func (s readOnlyStorage) Rename(oldName string, _ string) error {
+	return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrPermission}
+}
+
+func (s readOnlyStorage) MkdirAll(name string) error {
+	return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrPermission}
+}
+
+func (s readOnlyStorage) Chtimes(name string, _ time.Time, _ time.Time) error {
+	return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrPermission}
+}
+
+func (s readOnlyStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(s.fsys, filepath.ToSlash(name))
+}
+
+func (s readOnlyStorage) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(s.fsys, filepath.ToSlash(root), func(path string, d fs.DirEntry, err error) error {
+		return fn(filepath.FromSlash(path), d, err)
+	})
+}
+
+// readOnlyFile is the StorageFile of the fs.File.
+// If the fs.File doesn't implement the io.ReaderAt, it's read by seeking, if possible.
+type readOnlyFile struct {
+	fs.File
+
+	name string
+	mu   sync.Mutex
+}
+
+func (f *readOnlyFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if r, ok := f.File.(io.ReaderAt); ok {
+		return r.ReadAt(p, off)
+	}
+
+	seeker, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, fmt.Errorf("failed to read file %s at offset %d: file is not seekable", f.name, off)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := seeker.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	// The io.ReaderAt returns the io.EOF error, if fewer bytes are read.
+	n, err = io.ReadFull(f.File, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (f *readOnlyFile) Write(_ []byte) (n int, err error) {
+	return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+}
+
+func (f *readOnlyFile) Name() string {
+	return f.name
+}
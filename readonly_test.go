@@ -0,0 +1,223 @@
+package filecache_test
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekOnlyFS is the fs.FS with the files not implementing the io.ReaderAt.
+type seekOnlyFS struct {
+	fs.FS
+}
+
+func (fsys seekOnlyFS) Open(name string) (fs.File, error) {
+	f, err := fsys.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return seekOnlyFile{File: f}, nil
+}
+
+type seekOnlyFile struct {
+	fs.File
+}
+
+func (f seekOnlyFile) Seek(offset int64, whence int) (int64, error) {
+	return f.File.(io.Seeker).Seek(offset, whence)
+}
+
+// writeReadOnlyFixture writes the items to the dir with the file cache.
+func writeReadOnlyFixture(t *testing.T, dir string, options filecache.InstanceOptions) {
+	fc, err := filecache.New(dir, options)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = fc.WriteData(ctx, "key1", []byte("value1"), filecache.ItemOptions{
+		Name:   "Key 1",
+		Tags:   []string{"tag1"},
+		Fields: filecache.NewValues("field1", "val1"),
+	})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(ctx, "gzipped", []byte(strings.Repeat("value", 100)), filecache.ItemOptions{
+		Codec: filecache.NewGzipCodec(gzip.DefaultCompression),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, fc.Close())
+}
+
+func TestReadOnly_WriteRead(t *testing.T) {
+	dir := getTarget(t, "readonly")
+	options := filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath}
+
+	writeReadOnlyFixture(t, dir, options)
+
+	for name, fsys := range map[string]fs.FS{"dir": os.DirFS(dir), "seek": seekOnlyFS{FS: os.DirFS(dir)}} {
+		fc := filecache.NewReadOnly(fsys, options)
+		ctx := context.Background()
+
+		res, err := fc.Read(ctx, "key1")
+		require.NoError(t, err, name)
+		require.True(t, res.Hit(), name)
+		assert.Equal(t, "value1", string(res.Data()), name)
+		assert.Equal(t, "Key 1", res.Options().Name, name)
+		assert.Equal(t, []string{"tag1"}, res.Options().Tags, name)
+		assert.Equal(t, "val1", res.Options().Fields["field1"], name)
+
+		openRes, err := fc.Open(ctx, "key1")
+		require.NoError(t, err, name)
+		require.True(t, openRes.Hit(), name)
+		assert.Equal(t, int64(6), openRes.Size(), name)
+
+		_, err = openRes.Reader().Seek(2, io.SeekStart)
+		require.NoError(t, err, name)
+
+		data, err := io.ReadAll(openRes.Reader())
+		require.NoError(t, err, name)
+		require.NoError(t, openRes.Reader().Close(), name)
+		assert.Equal(t, "lue1", string(data), name)
+
+		res, err = fc.Read(ctx, "gzipped")
+		require.NoError(t, err, name)
+		require.True(t, res.Hit(), name)
+		assert.Equal(t, strings.Repeat("value", 100), string(res.Data()), name)
+
+		res, err = fc.Read(ctx, "unknown")
+		require.NoError(t, err, name)
+		assert.False(t, res.Hit(), name)
+		assert.ErrorIs(t, res.MissReason(), filecache.ErrNotFound, name)
+
+		assert.Equal(t, ".", fc.GetPath(), name)
+		assert.NoError(t, fc.Close(), name)
+	}
+}
+
+func TestReadOnly_WhenExpired_ExpectMissAndKept(t *testing.T) {
+	dir := getTarget(t, "readonly")
+
+	fc, err := filecache.New(dir)
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "key", []byte("value"), filecache.ItemOptions{
+		TTL: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	listFiles := func() []string {
+		files := make([]string, 0)
+
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+
+			return err
+		})
+		require.NoError(t, err)
+
+		return files
+	}
+
+	before := listFiles()
+
+	res, err := filecache.NewReadOnly(os.DirFS(dir)).Read(context.Background(), "key")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+	assert.ErrorIs(t, res.MissReason(), filecache.ErrExpired)
+
+	assert.Equal(t, before, listFiles())
+}
+
+func TestReadOnly_WhenModifying_ExpectReadOnlyError(t *testing.T) {
+	dir := getTarget(t, "readonly")
+
+	writeReadOnlyFixture(t, dir, filecache.InstanceOptions{})
+
+	fc := filecache.NewReadOnly(os.DirFS(dir))
+	ctx := context.Background()
+
+	loader := func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+		return strings.NewReader("loaded"), filecache.ItemOptions{}, nil
+	}
+
+	calls := map[string]func() error{
+		"Write": func() error {
+			_, err := fc.Write(ctx, "key", strings.NewReader("value"))
+
+			return err
+		},
+		"WriteData": func() error {
+			_, err := fc.WriteData(ctx, "key", []byte("value"))
+
+			return err
+		},
+		"OpenWriter": func() error {
+			_, err := fc.OpenWriter(ctx, "key")
+
+			return err
+		},
+		"GetOrWrite": func() error {
+			_, err := fc.GetOrWrite(ctx, "key", loader)
+
+			return err
+		},
+		"Invalidate": func() error {
+			return fc.Invalidate(ctx, "key1")
+		},
+		"InvalidateTags": func() error {
+			_, err := fc.InvalidateTags(ctx, "tag1")
+
+			return err
+		},
+		"InvalidatePrefix": func() error {
+			_, err := fc.InvalidatePrefix(ctx, "key")
+
+			return err
+		},
+		"InvalidateMatch": func() error {
+			_, err := fc.InvalidateMatch(ctx, func(_ filecache.ScanEntry) bool {
+				return true
+			})
+
+			return err
+		},
+		"Clear": func() error {
+			return fc.Clear(ctx)
+		},
+	}
+
+	for name, call := range calls {
+		var roErr *filecache.ReadOnlyError
+
+		err := call()
+		require.True(t, errors.As(err, &roErr), name)
+		assert.Equal(t, name, roErr.Op)
+	}
+
+	res, err := fc.GetOrWrite(ctx, "key1", loader)
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	require.NoError(t, res.Reader().Close())
+
+	res, err = fc.Open(ctx, "key1")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	require.NoError(t, res.Reader().Close())
+}
@@ -1,12 +1,15 @@
 package filecache
 
-import "io"
+import "time"
 
 // OpenResult is a result of the file cache's Open operation.
 type OpenResult struct {
-	hit     bool
-	reader  io.ReadCloser
-	options *ItemOptions
+	hit        bool
+	reader     ItemReader
+	options    *ItemOptions
+	size       int64
+	modTime    time.Time
+	missReason error
 }
 
 // Hit returns true, if requested key found in cache.
@@ -15,20 +18,41 @@ func (r *OpenResult) Hit() bool {
 }
 
 // Reader returns the cached data reader.
-func (r *OpenResult) Reader() io.ReadCloser {
+// The reader supports seeking and random access reading, see the ItemReader for details.
+func (r *OpenResult) Reader() ItemReader {
 	return r.reader
 }
 
+// Size returns the size of the cached data, or -1 if it's unknown.
+// The size is unknown for the compressed or encrypted items written without the size stored,
+// the reader's Seek to the end counts it by decoding the data.
+func (r *OpenResult) Size() int64 {
+	return r.size
+}
+
+// ModTime returns the time when the cached item has been written.
+func (r *OpenResult) ModTime() time.Time {
+	return r.modTime
+}
+
 // Options returns a found cache item options.
 func (r *OpenResult) Options() *ItemOptions {
 	return r.options
 }
 
+// MissReason returns the reason of the cache miss, or nil on the cache hit.
+// The reason matches one of the ErrNotFound, ErrExpired, ErrCorruptedMeta, ErrCorrupted,
+// ErrKeyMismatch, ErrEncrypted or ErrUnknownEncryptionKey errors, check it with the errors.Is function.
+func (r *OpenResult) MissReason() error {
+	return r.missReason
+}
+
 // ReadResult is a result of the file cache's Read operation.
 type ReadResult struct {
-	hit     bool
-	data    []byte
-	options *ItemOptions
+	hit        bool
+	data       []byte
+	options    *ItemOptions
+	missReason error
 }
 
 // Hit returns true, if requested key found in cache.
@@ -45,3 +69,9 @@ func (r *ReadResult) Data() []byte {
 func (r *ReadResult) Options() *ItemOptions {
 	return r.options
 }
+
+// MissReason returns the reason of the cache miss, or nil on the cache hit.
+// See the OpenResult's MissReason for the possible reasons.
+func (r *ReadResult) MissReason() error {
+	return r.missReason
+}
@@ -2,7 +2,6 @@ package filecache
 
 import (
 	"io/fs"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -17,6 +16,12 @@ type ScanEntry struct {
 	// CreatedAt is a cache item created-at timestamp.
 	CreatedAt time.Time
 
+	// AccessedAt is a cache item last access timestamp.
+	AccessedAt time.Time
+
+	// Size is a size of the cache item's data file in bytes.
+	Size int64
+
 	// Options are the options of the item stored in the cache.
 	Options *ItemOptions
 
@@ -30,30 +35,47 @@ type ScanEntry struct {
 type ScannerHitFn func(entry ScanEntry) error
 
 // NewScanner creates a Scanner looking for the valid cache items.
-func NewScanner(dir string) Scanner {
-	return &scanner{dir: dir}
+//
+// The instance options are required to scan the encrypted items:
+// the items, which meta can't be decrypted, are skipped.
+func NewScanner(dir string, options ...InstanceOptions) Scanner {
+	env := &itemsEnv{}
+
+	if len(options) > 0 {
+		env.crypt = newEncryptor(options[0].Encryption)
+		env.storage = options[0].Storage
+	}
+
+	return newScanner(dir, scanValid, env)
 }
 
-// newExpiredScanner creates a Scanner looking for the expired items.
-func newExpiredScanner(dir string) Scanner {
-	return &scanner{
-		dir:         dir,
-		expiredOnly: true,
-	}
+// newScanner creates a Scanner looking for the items in the mode within the environment.
+func newScanner(dir string, mode scanMode, env *itemsEnv) Scanner {
+	return &scanner{dir: dir, mode: mode, env: env}
 }
 
+type scanMode int
+
+const (
+	scanValid scanMode = iota
+	scanExpired
+)
+
 // Scanner is a tool to scan cache items inside the specified directory.
 type Scanner interface {
 	Scan(onHit ScannerHitFn) error
 }
 
 type scanner struct {
-	dir         string
-	expiredOnly bool
+	dir  string
+	mode scanMode
+	env  *itemsEnv
 }
 
 func (s *scanner) Scan(onHit ScannerHitFn) error {
-	return filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+	storage := s.env.getStorage()
+
+	return storage.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -69,26 +91,29 @@ func (s *scanner) Scan(onHit ScannerHitFn) error {
 		itemPath := strings.TrimSuffix(path, util.MetaSuffix)
 		metaPath := path
 
-		if !util.ItemFilesValid(itemPath, metaPath) {
+		itemStat, metaStat, ok := util.StatItemFiles(storage, itemPath, metaPath)
+		if !ok {
 			return nil
 		}
 
-		meta, err := readMeta("", path)
+		meta, err := s.env.readMeta("", path)
 		if err != nil {
 			//nolint:nilerr
 			return nil
 		}
 
-		if s.expiredOnly && !meta.isExpired() || !s.expiredOnly && meta.isExpired() {
+		if meta.isExpired() != (s.mode == scanExpired) {
 			return nil
 		}
 
 		return onHit(ScanEntry{
-			Key:       meta.Key,
-			CreatedAt: meta.CreatedAt,
-			Options:   metaToOptions(meta),
-			itemPath:  itemPath,
-			metaPath:  metaPath,
+			Key:        meta.Key,
+			CreatedAt:  meta.CreatedAt,
+			AccessedAt: metaStat.ModTime(),
+			Size:       itemStat.Size(),
+			Options:    metaToOptions(meta),
+			itemPath:   itemPath,
+			metaPath:   metaPath,
 		})
 	})
 }
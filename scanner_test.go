@@ -44,6 +44,9 @@ func TestScanner(t *testing.T) {
 	err = scanner.Scan(func(entry filecache.ScanEntry) error {
 		scannedKeys = append(scannedKeys, entry.Key)
 
+		assert.Equal(t, int64(6), entry.Size)
+		assert.False(t, entry.AccessedAt.IsZero())
+
 		return nil
 	})
 
@@ -0,0 +1,157 @@
+package filecache
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// Storage is a file system storing the cache files, defined by the InstanceOptions' Storage.
+//
+// The paths passed to the Storage are built by joining the cache dir and the paths of the PathGenerator
+// with the filepath.Join. The Storage might be implemented over an in-memory file system,
+// an overlay or a fault-injecting wrapper of the OS storage returned by the NewOSStorage.
+type Storage interface {
+	// CreateTemp creates a new file for writing in the dir, as the os.CreateTemp does:
+	// the file's name is the pattern with its last "*" replaced by a random string.
+	// The file is published to its target path by the Rename.
+	CreateTemp(dir string, pattern string) (StorageFile, error)
+
+	// Open opens the file for reading.
+	Open(name string) (StorageFile, error)
+
+	// Stat returns the file's info; the error matches the fs.ErrNotExist, if the file doesn't exist.
+	Stat(name string) (fs.FileInfo, error)
+
+	// Remove removes the file or the empty dir.
+	Remove(name string) error
+
+	// Rename moves the file, replacing the existing one.
+	Rename(oldName string, newName string) error
+
+	// MkdirAll creates the dir with all its parents, if they don't exist.
+	MkdirAll(name string) error
+
+	// Chtimes changes the file's access and modification times.
+	// The modification time of the item's meta file is the item's last access time.
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+
+	// ReadDir reads the dir's entries sorted by name.
+	ReadDir(name string) ([]fs.DirEntry, error)
+
+	// WalkDir walks the file tree of the root, as the filepath.WalkDir does.
+	WalkDir(root string, fn fs.WalkDirFunc) error
+}
+
+// StorageFile is a file of the Storage.
+// The files returned by the Storage's Open function are only read,
+// and the files returned by the CreateTemp function are only written.
+type StorageFile interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.Closer
+
+	// Name returns the file's path as passed to the Storage.
+	Name() string
+
+	// Stat returns the file's info.
+	Stat() (fs.FileInfo, error)
+}
+
+// NewOSStorage returns the Storage of the operating system's file system, used by default.
+func NewOSStorage() Storage {
+	return osStorage{}
+}
+
+type osStorage struct {
+	util.OSFS
+}
+
+func (osStorage) CreateTemp(dir string, pattern string) (StorageFile, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Chmod(util.FilesMode); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (osStorage) Open(name string) (StorageFile, error) {
+	return os.Open(name)
+}
+
+func (osStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osStorage) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
+
+// isOSStorage returns true if the storage is the operating system's one.
+func isOSStorage(storage Storage) bool {
+	_, ok := storage.(osStorage)
+
+	return ok
+}
+
+// createTempCacheFile creates a temporary file in the dir of the target path.
+// The file is published to the target path by the util.PublishCacheFiles function.
+func createTempCacheFile(storage Storage, key string, path string) (StorageFile, error) {
+	f, err := storage.CreateTemp(util.TempCacheFilePattern(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file for cache key %s: %w", key, err)
+	}
+
+	return f, nil
+}
+
+// readFile reads the whole file from the storage.
+func readFile(storage Storage, name string) ([]byte, error) {
+	f, err := storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.ReadAll(f)
+}
+
+// writeFile writes the file to the storage, publishing it when it's completely written.
+func writeFile(storage Storage, name string, data []byte) error {
+	f, err := storage.CreateTemp(util.TempCacheFilePattern(name))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = storage.Rename(f.Name(), name)
+	}
+
+	if err != nil {
+		_ = storage.Remove(f.Name())
+	}
+
+	return err
+}
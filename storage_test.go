@@ -0,0 +1,190 @@
+package filecache_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingStorage is the OS storage recording the calls and failing the renames, if the error is set.
+type recordingStorage struct {
+	filecache.Storage
+
+	mu        sync.Mutex
+	calls     map[string]int
+	renameErr error
+}
+
+func newRecordingStorage() *recordingStorage {
+	return &recordingStorage{Storage: filecache.NewOSStorage(), calls: make(map[string]int)}
+}
+
+func (s *recordingStorage) record(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[method]++
+}
+
+func (s *recordingStorage) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+func (s *recordingStorage) CreateTemp(dir string, pattern string) (filecache.StorageFile, error) {
+	s.record("CreateTemp")
+
+	return s.Storage.CreateTemp(dir, pattern)
+}
+
+func (s *recordingStorage) Open(name string) (filecache.StorageFile, error) {
+	s.record("Open")
+
+	return s.Storage.Open(name)
+}
+
+func (s *recordingStorage) Remove(name string) error {
+	s.record("Remove")
+
+	return s.Storage.Remove(name)
+}
+
+func (s *recordingStorage) Rename(oldName string, newName string) error {
+	s.record("Rename")
+
+	if s.renameErr != nil {
+		return s.renameErr
+	}
+
+	return s.Storage.Rename(oldName, newName)
+}
+
+func (s *recordingStorage) WalkDir(root string, fn fs.WalkDirFunc) error {
+	s.record("WalkDir")
+
+	return s.Storage.WalkDir(root, fn)
+}
+
+func TestStorage_WhenCustom_ExpectUsed(t *testing.T) {
+	dir := getTarget(t, "storage")
+	storage := newRecordingStorage()
+	ctx := context.Background()
+
+	fc, err := filecache.New(dir, filecache.InstanceOptions{
+		Storage: storage,
+		GC:      filecache.NewNopGarbageCollector(),
+	})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(ctx, "key1", []byte("value1"), filecache.ItemOptions{Tags: []string{"tag1"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, storage.count("CreateTemp"), "item, meta and tag entry files")
+	assert.Equal(t, 3, storage.count("Rename"))
+
+	res, err := fc.Read(ctx, "key1")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	assert.Equal(t, "value1", string(res.Data()))
+	assert.Positive(t, storage.count("Open"))
+
+	scanner := filecache.NewScanner(dir, filecache.InstanceOptions{Storage: storage})
+
+	err = scanner.Scan(func(entry filecache.ScanEntry) error {
+		assert.Equal(t, "key1", entry.Key)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, storage.count("WalkDir"))
+
+	removed, err := fc.InvalidateTags(ctx, "tag1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Positive(t, storage.count("Remove"))
+
+	require.NoError(t, fc.Clear(ctx))
+	assert.Equal(t, 2, storage.count("WalkDir"))
+}
+
+func TestStorage_WhenGC_ExpectUsed(t *testing.T) {
+	dir := getTarget(t, "storage")
+	storage := newRecordingStorage()
+	ctx := context.Background()
+
+	fc, err := filecache.New(dir, filecache.InstanceOptions{
+		Storage: storage,
+		GC:      filecache.NewNopGarbageCollector(),
+	})
+	require.NoError(t, err)
+
+	for _, key := range []string{"key1", "key2"} {
+		_, err = fc.WriteData(ctx, key, []byte("value"))
+		require.NoError(t, err)
+	}
+
+	limited, err := filecache.New(dir, filecache.InstanceOptions{Storage: storage, MaxItems: 1})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = limited.Close()
+	})
+
+	assert.Eventually(t, func() bool {
+		count := 0
+
+		_ = filecache.NewScanner(dir).Scan(func(_ filecache.ScanEntry) error {
+			count++
+
+			return nil
+		})
+
+		return count == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Positive(t, storage.count("WalkDir"))
+}
+
+func TestStorage_WhenRenameFails_ExpectWriteError(t *testing.T) {
+	dir := getTarget(t, "storage")
+	storage := newRecordingStorage()
+	storage.renameErr = errors.New("test error")
+
+	fc, err := filecache.New(dir, filecache.InstanceOptions{Storage: storage})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "key1", []byte("value1"))
+	assert.ErrorIs(t, err, storage.renameErr)
+
+	res, err := fc.Read(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		assert.False(t, strings.HasSuffix(path, ".tmp"), path)
+
+		return err
+	})
+	require.NoError(t, err)
+}
+
+func TestStorage_WhenFileLockingWithCustomStorage_ExpectError(t *testing.T) {
+	_, err := filecache.New(getTarget(t, "storage"), filecache.InstanceOptions{
+		Storage:     newRecordingStorage(),
+		FileLocking: true,
+	})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package filecache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// TagsDir is a name of the dir with the tags index inside the cache dir.
+const TagsDir = ".filecache-tags"
+
+// newTagsIndex creates a tagsIndex of the cache dir in the items' environment.
+// If the environment has the encryptor, the keys stored in the index are encrypted.
+func newTagsIndex(dir string, env *itemsEnv) *tagsIndex {
+	return &tagsIndex{dir: filepath.Join(dir, TagsDir), crypt: env.getCrypt(), storage: env.getStorage()}
+}
+
+// tagsIndex is an index of the tagged items stored inside the cache dir.
+//
+// Every tag has its own dir, containing a file per tagged item:
+// the file is named by the hashed item's key and contains the key itself.
+// The index might contain stale entries (e.g., if the item is expired or rewritten without the tag),
+// so the items found by the index must be verified by their meta.
+type tagsIndex struct {
+	dir     string
+	crypt   *encryptor
+	storage Storage
+}
+
+// add adds the key to the tags' entries.
+func (idx *tagsIndex) add(key string, tags []string) error {
+	for _, tag := range tags {
+		tagDir := idx.getTagDir(tag)
+
+		if err := idx.storage.MkdirAll(tagDir); err != nil {
+			return fmt.Errorf("failed to create tag dir for tag %s: %w", tag, err)
+		}
+
+		data := []byte(key)
+
+		if idx.crypt != nil {
+			var err error
+
+			if data, err = seal(data, idx.crypt); err != nil {
+				return fmt.Errorf("failed to encrypt key %s for tag %s: %w", key, tag, err)
+			}
+		}
+
+		if err := writeFile(idx.storage, idx.getEntryPath(tag, key), data); err != nil {
+			return fmt.Errorf("failed to add key %s to tag %s: %w", key, tag, err)
+		}
+	}
+
+	return nil
+}
+
+// remove removes the key from the tags' entries.
+func (idx *tagsIndex) remove(key string, tags []string) {
+	for _, tag := range tags {
+		_ = idx.storage.Remove(idx.getEntryPath(tag, key))
+	}
+}
+
+// keys returns the keys of the tag's entries.
+func (idx *tagsIndex) keys(tag string) ([]string, error) {
+	tagDir := idx.getTagDir(tag)
+
+	entries, err := idx.storage.ReadDir(tagDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read tag dir for tag %s: %w", tag, err)
+	}
+
+	keys := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		// The entries being written are skipped.
+		if _, tmp := util.TempCacheFileTarget(entry.Name()); entry.IsDir() || tmp {
+			continue
+		}
+
+		key, err := idx.readEntry(filepath.Join(tagDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (idx *tagsIndex) readEntry(path string) (key string, err error) {
+	data, err := readFile(idx.storage, path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err = openSealed(data, idx.crypt)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func (idx *tagsIndex) getTagDir(tag string) string {
+	return filepath.Join(idx.dir, HashedKeyPath(tag))
+}
+
+func (idx *tagsIndex) getEntryPath(tag string, key string) string {
+	return filepath.Join(idx.getTagDir(tag), HashedKeyPath(key))
+}
+
+// hasAnyTag checks if the item's tags contain any of the tags.
+func hasAnyTag(itemTags []string, tags []string) bool {
+	for _, t := range itemTags {
+		for _, tag := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tagsDiff returns the tags from a, which are not in b.
+func tagsDiff(a []string, b []string) []string {
+	diff := make([]string, 0, len(a))
+
+	for _, tag := range a {
+		found := false
+
+		for _, t := range b {
+			if t == tag {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			diff = append(diff, tag)
+		}
+	}
+
+	return diff
+}
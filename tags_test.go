@@ -0,0 +1,113 @@
+package filecache_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCache_InvalidateTags(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "tags"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	items := map[string][]string{
+		"page1": {"product:1", "category:1"},
+		"page2": {"product:2", "category:1"},
+		"page3": {"product:3"},
+		"page4": nil,
+	}
+
+	for key, tags := range items {
+		_, err := fc.WriteData(ctx, key, []byte(key), filecache.ItemOptions{Tags: tags})
+		require.NoError(t, err)
+	}
+
+	// Rewritten without the tag, so it's not invalidated by it.
+	_, err = fc.WriteData(ctx, "page3", []byte("page3"), filecache.ItemOptions{Tags: []string{"product:4"}})
+	require.NoError(t, err)
+
+	res, err := fc.Read(ctx, "page1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"product:1", "category:1"}, res.Options().Tags)
+
+	removed, err := fc.InvalidateTags(ctx, "category:1", "product:3", "unknown")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	for key, expectHit := range map[string]bool{"page1": false, "page2": false, "page3": true, "page4": true} {
+		res, err := fc.Read(ctx, key)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectHit, res.Hit(), key)
+	}
+
+	// Already removed items are not counted again.
+	removed, err = fc.InvalidateTags(ctx, "product:1", "product:2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	removed, err = fc.InvalidateTags(ctx, "product:4")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestFileCache_InvalidateTags_WhenInvalidated_ExpectIndexCleaned(t *testing.T) {
+	target := getTarget(t, "tags")
+
+	fc, err := filecache.New(target)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = fc.WriteData(ctx, "test", []byte("value"), filecache.ItemOptions{Tags: []string{"tag1", "tag2"}})
+	require.NoError(t, err)
+
+	tag1Dir := filepath.Join(target, filecache.TagsDir, filecache.HashedKeyPath("tag1"))
+	tag2Dir := filepath.Join(target, filecache.TagsDir, filecache.HashedKeyPath("tag2"))
+
+	assertEntries(t, tag1Dir, 1)
+	assertEntries(t, tag2Dir, 1)
+
+	// Rewrite without the tag1.
+	_, err = fc.WriteData(ctx, "test", []byte("value"), filecache.ItemOptions{Tags: []string{"tag2"}})
+	require.NoError(t, err)
+
+	assertEntries(t, tag1Dir, 0)
+	assertEntries(t, tag2Dir, 1)
+
+	require.NoError(t, fc.Invalidate(ctx, "test"))
+
+	assertEntries(t, tag2Dir, 0)
+}
+
+func TestFileCache_InvalidateTags_WhenContextCanceled_ExpectError(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "tags"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	removed, err := fc.InvalidateTags(ctx, "tag")
+
+	assert.Equal(t, 0, removed)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func assertEntries(t *testing.T, dir string, expected int) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	assert.Len(t, entries, expected)
+}
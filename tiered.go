@@ -0,0 +1,301 @@
+package filecache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+const (
+	defaultMemoryTierMaxSize     = 64 * 1024 * 1024
+	defaultMemoryTierMaxItemSize = 1024 * 1024
+
+	// accessTouchInterval is a minimal interval between the updates
+	// of the file cache item's access time by the memory tier's hits.
+	accessTouchInterval = time.Second
+)
+
+// accessToucher is the FileCache tracking the items' access time, e.g., for the LRU GC.
+type accessToucher interface {
+	touchAccess(key string)
+}
+
+// MemoryTierOptions are the options of the tiered FileCache's in-memory tier.
+type MemoryTierOptions struct {
+	// MaxSize is the maximum total size of the items' data kept in memory in bytes, 64 MiB by default.
+	// The least recently used items are evicted when the size is exceeded.
+	MaxSize int64
+
+	// MaxItemSize is the maximum size of the item's data kept in memory in bytes, 1 MiB by default.
+	// The larger items are always read from the file cache.
+	MaxItemSize int64
+
+	// TTL is the maximum time the item is kept in memory, regardless of its own TTL.
+	// Limits the time the memory tier might serve the items changed by another instance or process
+	// sharing the file cache's dir. No limit by default.
+	TTL time.Duration
+}
+
+// NewTiered creates the FileCache keeping the small, recently used items of the file cache in memory.
+//
+// The items are read from memory when possible, and read from the file cache and kept in memory otherwise.
+// The writes and invalidations are applied to the file cache, and the affected items are removed from memory,
+// so the instance reads its own writes. The changes made to the file cache by another instance
+// (e.g., the GC deletions or the writes of another process) are not seen while the item is kept in memory,
+// see the MemoryTierOptions' TTL.
+func NewTiered(fc FileCache, options ...MemoryTierOptions) FileCache {
+	opt := MemoryTierOptions{}
+
+	if len(options) > 0 {
+		opt = options[0]
+	}
+
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = defaultMemoryTierMaxSize
+	}
+
+	if opt.MaxItemSize <= 0 {
+		opt.MaxItemSize = defaultMemoryTierMaxItemSize
+	}
+
+	if opt.MaxItemSize > opt.MaxSize {
+		opt.MaxItemSize = opt.MaxSize
+	}
+
+	return &tieredFileCache{
+		fc:          fc,
+		mem:         newMemoryStore(opt.MaxSize, 0, opt.TTL),
+		maxItemSize: opt.MaxItemSize,
+	}
+}
+
+type tieredFileCache struct {
+	fc          FileCache
+	mem         *memoryStore
+	maxItemSize int64
+}
+
+func (fc *tieredFileCache) GetPath() string {
+	return fc.fc.GetPath()
+}
+
+func (fc *tieredFileCache) Write(
+	ctx context.Context,
+	key string,
+	reader io.Reader,
+	options ...ItemOptions,
+) (written int64, err error) {
+	w, err := fc.OpenWriter(ctx, key, options...)
+	if err != nil {
+		return 0, err
+	}
+
+	return copyToWriter(ctx, w, reader)
+}
+
+func (fc *tieredFileCache) WriteData(
+	ctx context.Context,
+	key string,
+	data []byte,
+	options ...ItemOptions,
+) (written int64, err error) {
+	return fc.Write(ctx, key, bytes.NewReader(data), options...)
+}
+
+func (fc *tieredFileCache) OpenWriter(ctx context.Context, key string, options ...ItemOptions) (ItemWriter, error) {
+	w, err := fc.fc.OpenWriter(ctx, key, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tieredItemWriter{ItemWriter: w, key: key, mem: fc.mem}, nil
+}
+
+func (fc *tieredFileCache) Open(ctx context.Context, key string) (result *OpenResult, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if item, _ := fc.mem.get(key); item != nil {
+		fc.touchAccess(item)
+
+		return item.openResult(), nil
+	}
+
+	epoch := fc.mem.currentEpoch()
+
+	result, err = fc.fc.Open(ctx, key)
+	if err != nil || !result.Hit() {
+		return result, err
+	}
+
+	return fc.keep(ctx, key, epoch, result)
+}
+
+func (fc *tieredFileCache) Read(ctx context.Context, key string) (result *ReadResult, err error) {
+	openRes, err := fc.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &ReadResult{}
+
+	if !openRes.Hit() {
+		result.missReason = openRes.missReason
+
+		return result, nil
+	}
+
+	defer func() {
+		_ = openRes.reader.Close()
+	}()
+
+	data, err := util.ReadAll(ctx, openRes.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache data for key %s: %w", key, err)
+	}
+
+	result.hit = true
+	result.options = openRes.options
+	result.data = data
+
+	return result, nil
+}
+
+func (fc *tieredFileCache) GetOrWrite(
+	ctx context.Context,
+	key string,
+	loader LoaderFn,
+) (result *OpenResult, err error) {
+	result, err = fc.Open(ctx, key)
+	if err != nil || result.Hit() {
+		return result, err
+	}
+
+	epoch := fc.mem.currentEpoch()
+
+	result, err = fc.fc.GetOrWrite(ctx, key, loader)
+	if err != nil || !result.Hit() {
+		return result, err
+	}
+
+	return fc.keep(ctx, key, epoch, result)
+}
+
+func (fc *tieredFileCache) Invalidate(ctx context.Context, key string) error {
+	defer fc.mem.remove(key)
+
+	return fc.fc.Invalidate(ctx, key)
+}
+
+func (fc *tieredFileCache) InvalidateTags(ctx context.Context, tags ...string) (removed int, err error) {
+	defer fc.mem.removeMatch(func(item *memoryItem) bool {
+		return hasAnyTag(item.options.Tags, tags)
+	})
+
+	return fc.fc.InvalidateTags(ctx, tags...)
+}
+
+func (fc *tieredFileCache) InvalidatePrefix(ctx context.Context, prefix string) (removed int, err error) {
+	defer fc.mem.removeMatch(func(item *memoryItem) bool {
+		return strings.HasPrefix(item.key, prefix)
+	})
+
+	return fc.fc.InvalidatePrefix(ctx, prefix)
+}
+
+func (fc *tieredFileCache) InvalidateMatch(
+	ctx context.Context,
+	match func(entry ScanEntry) bool,
+) (removed int, err error) {
+	matched := make([]string, 0)
+
+	defer func() {
+		fc.mem.remove(matched...)
+	}()
+
+	return fc.fc.InvalidateMatch(ctx, func(entry ScanEntry) bool {
+		if !match(entry) {
+			return false
+		}
+
+		matched = append(matched, entry.Key)
+
+		return true
+	})
+}
+
+func (fc *tieredFileCache) Clear(ctx context.Context) error {
+	defer fc.mem.clear()
+
+	return fc.fc.Clear(ctx)
+}
+
+func (fc *tieredFileCache) Close() error {
+	fc.mem.clear()
+
+	return fc.fc.Close()
+}
+
+// keep reads the opened item to memory, if it's small enough, and returns the result reading it from memory.
+// The item is not kept, if the items have been changed since the epoch.
+func (fc *tieredFileCache) keep(ctx context.Context, key string, epoch uint64, res *OpenResult) (*OpenResult, error) {
+	if res.size < 0 || res.size > fc.maxItemSize {
+		return res, nil
+	}
+
+	defer func() {
+		_ = res.reader.Close()
+	}()
+
+	// The corrupted item is removed by the reader itself.
+	data, err := util.ReadAll(ctx, res.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache data for key %s: %w", key, err)
+	}
+
+	item, err := fc.mem.newItem(key, data, res.options, res.modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	fc.mem.put(epoch, item)
+
+	return item.openResult(), nil
+}
+
+// touchAccess updates the file cache item's access time, so the items read from memory
+// are not considered as the least recently used ones by the file cache's GC.
+func (fc *tieredFileCache) touchAccess(item *memoryItem) {
+	toucher, ok := fc.fc.(accessToucher)
+	if !ok || !fc.mem.touch(item, accessTouchInterval) {
+		return
+	}
+
+	toucher.touchAccess(item.key)
+}
+
+// tieredItemWriter is the ItemWriter removing the item from the memory tier when it's published.
+type tieredItemWriter struct {
+	ItemWriter
+
+	key string
+	mem *memoryStore
+}
+
+func (w *tieredItemWriter) Close() error {
+	defer w.mem.remove(w.key)
+
+	return w.ItemWriter.Close()
+}
+
+func (w *tieredItemWriter) Commit() error {
+	defer w.mem.remove(w.key)
+
+	return w.ItemWriter.Commit()
+}
@@ -0,0 +1,293 @@
+package filecache_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTiered(t *testing.T, options ...filecache.MemoryTierOptions) (tiered, files filecache.FileCache) {
+	files, err := filecache.New(getTarget(t, "tiered"), filecache.InstanceOptions{
+		GC: filecache.NewNopGarbageCollector(),
+	})
+	require.NoError(t, err)
+
+	return filecache.NewTiered(files, options...), files
+}
+
+func assertTieredData(t *testing.T, fc filecache.FileCache, key string, expected string) {
+	t.Helper()
+
+	res, err := fc.Read(context.Background(), key)
+	require.NoError(t, err)
+
+	if expected == "" {
+		assert.False(t, res.Hit(), key)
+
+		return
+	}
+
+	require.True(t, res.Hit(), key)
+	assert.Equal(t, expected, string(res.Data()), key)
+}
+
+func TestTiered_WhenRead_ExpectKeptInMemory(t *testing.T) {
+	fc, files := newTestTiered(t)
+	ctx := context.Background()
+
+	_, err := fc.WriteData(ctx, "key", []byte("value1"), filecache.ItemOptions{
+		Name:   "item",
+		Fields: filecache.NewValues("field", "value"),
+	})
+	require.NoError(t, err)
+
+	assertTieredData(t, fc, "key", "value1")
+
+	// The item is removed from the file cache only, so it's read from memory.
+	require.NoError(t, files.Invalidate(ctx, "key"))
+
+	res, err := fc.Read(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+	assert.Equal(t, "value1", string(res.Data()))
+	assert.Equal(t, "item", res.Options().Name)
+	assert.Equal(t, "value", res.Options().Fields["field"])
+
+	res.Options().Fields["field"] = "modified"
+
+	openRes, err := fc.Open(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, openRes.Hit())
+	assert.Equal(t, "value", openRes.Options().Fields["field"])
+	assert.Equal(t, int64(6), openRes.Size())
+
+	_, err = openRes.Reader().Seek(2, io.SeekStart)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(openRes.Reader())
+	require.NoError(t, err)
+	assert.Equal(t, "lue1", string(data))
+	require.NoError(t, openRes.Reader().Close())
+
+	_, err = fc.WriteData(ctx, "key", []byte("value2"))
+	require.NoError(t, err)
+
+	assertTieredData(t, fc, "key", "value2")
+	assertTieredData(t, files, "key", "value2")
+}
+
+func TestTiered_WhenOpenWriter_ExpectRemovedFromMemory(t *testing.T) {
+	fc, _ := newTestTiered(t)
+	ctx := context.Background()
+
+	_, err := fc.WriteData(ctx, "key", []byte("value1"))
+	require.NoError(t, err)
+
+	assertTieredData(t, fc, "key", "value1")
+
+	w, err := fc.OpenWriter(ctx, "key")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("value2"))
+	require.NoError(t, err)
+
+	assertTieredData(t, fc, "key", "value1")
+
+	require.NoError(t, w.Close())
+
+	assertTieredData(t, fc, "key", "value2")
+}
+
+func TestTiered_WhenInvalidated_ExpectRemovedFromBothTiers(t *testing.T) {
+	tests := map[string]func(fc filecache.FileCache) error{
+		"key": func(fc filecache.FileCache) error {
+			return fc.Invalidate(context.Background(), "key1")
+		},
+		"tags": func(fc filecache.FileCache) error {
+			_, err := fc.InvalidateTags(context.Background(), "tag1")
+
+			return err
+		},
+		"prefix": func(fc filecache.FileCache) error {
+			_, err := fc.InvalidatePrefix(context.Background(), "key1")
+
+			return err
+		},
+		"match": func(fc filecache.FileCache) error {
+			_, err := fc.InvalidateMatch(context.Background(), func(entry filecache.ScanEntry) bool {
+				return entry.Key == "key1"
+			})
+
+			return err
+		},
+		"clear": func(fc filecache.FileCache) error {
+			return fc.Clear(context.Background())
+		},
+	}
+
+	for name, invalidate := range tests {
+		fc, files := newTestTiered(t)
+		ctx := context.Background()
+
+		_, err := fc.WriteData(ctx, "key1", []byte("value1"), filecache.ItemOptions{Tags: []string{"tag1"}})
+		require.NoError(t, err, name)
+
+		assertTieredData(t, fc, "key1", "value1")
+
+		require.NoError(t, invalidate(fc), name)
+
+		assertTieredData(t, fc, "key1", "")
+		assertTieredData(t, files, "key1", "")
+	}
+}
+
+func TestTiered_WhenLimitsExceeded_ExpectEvicted(t *testing.T) {
+	fc, files := newTestTiered(t, filecache.MemoryTierOptions{MaxSize: 10, MaxItemSize: 5})
+	ctx := context.Background()
+
+	items := map[string]string{"key1": "val-1", "key2": "val-2", "key3": "val-3", "large": "large-value"}
+
+	for key, value := range items {
+		_, err := fc.WriteData(ctx, key, []byte(value))
+		require.NoError(t, err)
+	}
+
+	assertTieredData(t, fc, "key1", "val-1")
+	assertTieredData(t, fc, "key2", "val-2")
+	assertTieredData(t, fc, "key1", "val-1")
+	assertTieredData(t, fc, "key3", "val-3")
+	assertTieredData(t, fc, "large", "large-value")
+
+	// Only the items kept in memory are read after the file cache is cleared.
+	require.NoError(t, files.Clear(ctx))
+
+	assertTieredData(t, fc, "key1", "val-1")
+	assertTieredData(t, fc, "key2", "")
+	assertTieredData(t, fc, "key3", "val-3")
+	assertTieredData(t, fc, "large", "")
+}
+
+func TestTiered_WhenExpired_ExpectRemovedFromMemory(t *testing.T) {
+	tests := map[string]struct {
+		memoryTTL time.Duration
+		itemTTL   time.Duration
+	}{
+		"memory ttl": {memoryTTL: 50 * time.Millisecond},
+		"item ttl":   {itemTTL: 50 * time.Millisecond},
+	}
+
+	for name, test := range tests {
+		fc, files := newTestTiered(t, filecache.MemoryTierOptions{TTL: test.memoryTTL})
+		ctx := context.Background()
+
+		_, err := fc.WriteData(ctx, "key", []byte("value"), filecache.ItemOptions{TTL: test.itemTTL})
+		require.NoError(t, err, name)
+
+		assertTieredData(t, fc, "key", "value")
+
+		require.NoError(t, files.Invalidate(ctx, "key"), name)
+
+		assertTieredData(t, fc, "key", "value")
+
+		time.Sleep(100 * time.Millisecond)
+
+		assertTieredData(t, fc, "key", "")
+	}
+}
+
+func TestTiered_GetOrWrite(t *testing.T) {
+	fc, files := newTestTiered(t)
+	ctx := context.Background()
+	calls := 0
+
+	loader := func(_ context.Context) (io.Reader, filecache.ItemOptions, error) {
+		calls++
+
+		return strings.NewReader("loaded"), filecache.ItemOptions{Name: "loaded"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		res, err := fc.GetOrWrite(ctx, "key", loader)
+		require.NoError(t, err)
+		require.True(t, res.Hit())
+
+		data, err := io.ReadAll(res.Reader())
+		require.NoError(t, err)
+		require.NoError(t, res.Reader().Close())
+
+		assert.Equal(t, "loaded", string(data))
+		assert.Equal(t, "loaded", res.Options().Name)
+	}
+
+	assert.Equal(t, 1, calls)
+	assertTieredData(t, files, "key", "loaded")
+}
+
+func TestTiered_WhenCorrupted_ExpectReadError(t *testing.T) {
+	for name, maxItemSize := range map[string]int64{"small": 0, "large": 4} {
+		target := getTarget(t, "tiered")
+
+		files, err := filecache.New(target, filecache.InstanceOptions{
+			PathGenerator: filecache.FilteredKeyPath,
+			GC:            filecache.NewNopGarbageCollector(),
+		})
+		require.NoError(t, err, name)
+
+		fc := filecache.NewTiered(files, filecache.MemoryTierOptions{MaxItemSize: maxItemSize})
+
+		_, err = fc.WriteData(context.Background(), "key", []byte("test value"))
+		require.NoError(t, err, name)
+
+		require.NoError(t, os.WriteFile(filepath.Join(target, "key"), []byte("TEST VALUE"), 0644), name)
+
+		res, err := fc.Read(context.Background(), "key")
+		assert.Nil(t, res, name)
+		assert.ErrorIs(t, err, filecache.ErrCorrupted, name)
+	}
+}
+
+func TestTiered_WhenReadFromMemory_ExpectAccessTimeUpdated(t *testing.T) {
+	fc, files := newTestTiered(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"hot", "cold"} {
+		_, err := fc.WriteData(ctx, key, []byte("value"))
+		require.NoError(t, err)
+	}
+
+	assertTieredData(t, fc, "hot", "value")
+	assertTieredData(t, files, "cold", "value")
+
+	accessedAt := func() map[string]time.Time {
+		times := make(map[string]time.Time)
+
+		err := filecache.NewScanner(files.GetPath()).Scan(func(entry filecache.ScanEntry) error {
+			times[entry.Key] = entry.AccessedAt
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		return times
+	}
+
+	require.True(t, accessedAt()["hot"].Before(accessedAt()["cold"]))
+
+	// The access time is updated once a second at most.
+	time.Sleep(1100 * time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		assertTieredData(t, fc, "hot", "value")
+	}
+
+	times := accessedAt()
+	assert.True(t, times["hot"].After(times["cold"]))
+}
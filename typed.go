@@ -0,0 +1,175 @@
+package filecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// Serializer converts the TypedCache values to the cached data and back.
+//
+// There are built-in serializers, created by the NewJSONSerializer,
+// NewGobSerializer and NewBytesSerializer functions.
+type Serializer[T any] interface {
+	// Marshal returns the data of the value.
+	Marshal(value T) ([]byte, error)
+
+	// Unmarshal returns the value of the data.
+	Unmarshal(data []byte) (T, error)
+}
+
+// NewJSONSerializer returns the Serializer encoding the values as JSON.
+func NewJSONSerializer[T any]() Serializer[T] {
+	return jsonSerializer[T]{}
+}
+
+// NewGobSerializer returns the Serializer encoding the values with the encoding/gob package.
+func NewGobSerializer[T any]() Serializer[T] {
+	return gobSerializer[T]{}
+}
+
+// NewBytesSerializer returns the Serializer storing the raw bytes as is.
+func NewBytesSerializer() Serializer[[]byte] {
+	return bytesSerializer{}
+}
+
+// TypedLoaderFn is a function returning the value to cache with its options on the TypedCache's miss.
+type TypedLoaderFn[T any] func(ctx context.Context) (value T, options ItemOptions, err error)
+
+// TypedCache is a FileCache wrapper storing the values of the T type.
+type TypedCache[T any] interface {
+	// Get returns the cached value by the key.
+	// If the value is not found, the ok flag is false.
+	Get(ctx context.Context, key string) (value T, ok bool, err error)
+
+	// Set caches the value by the key.
+	Set(ctx context.Context, key string, value T, options ...ItemOptions) error
+
+	// GetOrSet returns the cached value by the key.
+	// On a cache miss, the loader is called and its result is cached & returned.
+	// Concurrent misses of the same key call the loader only once, see the FileCache's GetOrWrite.
+	GetOrSet(ctx context.Context, key string, loader TypedLoaderFn[T]) (value T, err error)
+}
+
+// NewTyped creates the TypedCache storing the values in the FileCache, converted by the Serializer.
+func NewTyped[T any](fc FileCache, serializer Serializer[T]) TypedCache[T] {
+	return &typedCache[T]{fc: fc, serializer: serializer}
+}
+
+type typedCache[T any] struct {
+	fc         FileCache
+	serializer Serializer[T]
+}
+
+func (c *typedCache[T]) Get(ctx context.Context, key string) (value T, ok bool, err error) {
+	res, err := c.fc.Read(ctx, key)
+	if err != nil || !res.Hit() {
+		return value, false, err
+	}
+
+	value, err = c.unmarshal(key, res.Data())
+	if err != nil {
+		return value, false, err
+	}
+
+	return value, true, nil
+}
+
+func (c *typedCache[T]) Set(ctx context.Context, key string, value T, options ...ItemOptions) error {
+	data, err := c.serializer.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache value for key %s: %w", key, err)
+	}
+
+	_, err = c.fc.WriteData(ctx, key, data, options...)
+
+	return err
+}
+
+func (c *typedCache[T]) GetOrSet(ctx context.Context, key string, loader TypedLoaderFn[T]) (value T, err error) {
+	res, err := c.fc.GetOrWrite(ctx, key, func(ctx context.Context) (io.Reader, ItemOptions, error) {
+		value, options, err := loader(ctx)
+		if err != nil {
+			return nil, options, err
+		}
+
+		data, err := c.serializer.Marshal(value)
+		if err != nil {
+			return nil, options, fmt.Errorf("failed to marshal cache value for key %s: %w", key, err)
+		}
+
+		return bytes.NewReader(data), options, nil
+	})
+	if err != nil {
+		return value, err
+	}
+
+	if !res.Hit() {
+		return value, fmt.Errorf("failed to get cache value for key %s: %w", key, res.MissReason())
+	}
+
+	defer func() {
+		_ = res.Reader().Close()
+	}()
+
+	data, err := util.ReadAll(ctx, res.Reader())
+	if err != nil {
+		return value, fmt.Errorf("failed to read cache data for key %s: %w", key, err)
+	}
+
+	return c.unmarshal(key, data)
+}
+
+func (c *typedCache[T]) unmarshal(key string, data []byte) (value T, err error) {
+	value, err = c.serializer.Unmarshal(data)
+	if err != nil {
+		return value, fmt.Errorf("failed to unmarshal cache value for key %s: %w", key, err)
+	}
+
+	return value, nil
+}
+
+type jsonSerializer[T any] struct{}
+
+func (s jsonSerializer[T]) Marshal(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (s jsonSerializer[T]) Unmarshal(data []byte) (value T, err error) {
+	err = json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+type gobSerializer[T any] struct{}
+
+func (s gobSerializer[T]) Marshal(value T) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s gobSerializer[T]) Unmarshal(data []byte) (value T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+
+	return value, err
+}
+
+type bytesSerializer struct{}
+
+func (s bytesSerializer) Marshal(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+func (s bytesSerializer) Unmarshal(data []byte) ([]byte, error) {
+	return data, nil
+}
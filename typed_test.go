@@ -0,0 +1,129 @@
+package filecache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedTestValue struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestTypedCache_GetSet(t *testing.T) {
+	value := typedTestValue{Name: "test", Count: 42, Tags: []string{"a", "b"}}
+
+	tests := map[string]filecache.Serializer[typedTestValue]{
+		"json": filecache.NewJSONSerializer[typedTestValue](),
+		"gob":  filecache.NewGobSerializer[typedTestValue](),
+	}
+
+	for name, serializer := range tests {
+		fc, err := filecache.New(getTarget(t, "typed"))
+		require.NoError(t, err)
+
+		tc := filecache.NewTyped(fc, serializer)
+		ctx := context.Background()
+
+		got, ok, err := tc.Get(ctx, "key")
+		require.NoError(t, err, name)
+		assert.False(t, ok, name)
+		assert.Equal(t, typedTestValue{}, got, name)
+
+		err = tc.Set(ctx, "key", value, filecache.ItemOptions{Name: "typed"})
+		require.NoError(t, err, name)
+
+		got, ok, err = tc.Get(ctx, "key")
+		require.NoError(t, err, name)
+		assert.True(t, ok, name)
+		assert.Equal(t, value, got, name)
+
+		res, err := fc.Read(ctx, "key")
+		require.NoError(t, err, name)
+		assert.Equal(t, "typed", res.Options().Name, name)
+	}
+}
+
+func TestTypedCache_WhenBytes_ExpectRawData(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "typed"))
+	require.NoError(t, err)
+
+	tc := filecache.NewTyped(fc, filecache.NewBytesSerializer())
+	ctx := context.Background()
+
+	require.NoError(t, tc.Set(ctx, "key", []byte("raw value")))
+
+	res, err := fc.Read(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "raw value", string(res.Data()))
+
+	got, ok, err := tc.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "raw value", string(got))
+}
+
+func TestTypedCache_WhenInvalidData_ExpectError(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "typed"))
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "key", []byte("not a json"))
+	require.NoError(t, err)
+
+	_, ok, err := filecache.NewTyped(fc, filecache.NewJSONSerializer[int]()).Get(context.Background(), "key")
+
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestTypedCache_GetOrSet(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "typed"))
+	require.NoError(t, err)
+
+	tc := filecache.NewTyped(fc, filecache.NewJSONSerializer[typedTestValue]())
+	ctx := context.Background()
+
+	var (
+		calls atomic.Int32
+		wg    sync.WaitGroup
+	)
+
+	loader := func(ctx context.Context) (typedTestValue, filecache.ItemOptions, error) {
+		calls.Add(1)
+
+		return typedTestValue{Name: "loaded"}, filecache.ItemOptions{}, nil
+	}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			got, err := tc.GetOrSet(ctx, "key", loader)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "loaded", got.Name)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+
+	errLoader := errors.New("loader error")
+
+	_, err = tc.GetOrSet(ctx, "other", func(ctx context.Context) (typedTestValue, filecache.ItemOptions, error) {
+		return typedTestValue{}, filecache.ItemOptions{}, errLoader
+	})
+
+	assert.ErrorIs(t, err, errLoader)
+}
@@ -0,0 +1,283 @@
+package filecache
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/kukymbr/filecache/v2/internal/util"
+)
+
+// ItemWriter is a writer of the cache item data, returned by the FileCache's OpenWriter function.
+//
+// The written data is staged in a temporary file and published to the cache on Commit,
+// or discarded on Abort. The item's key stays locked until the writer is committed or aborted,
+// so one of these functions must always be called. The ItemWriter is not safe for concurrent use.
+type ItemWriter interface {
+	// Write writes data to the item.
+	Write(p []byte) (n int, err error)
+
+	// Close is an alias for the Commit, making the ItemWriter an io.WriteCloser.
+	// Returns no error, if the writer is already committed or aborted.
+	Close() error
+
+	// Commit publishes the written data to the cache.
+	// If the commit fails, the written data is discarded.
+	Commit() error
+
+	// Abort discards the written data.
+	// Returns no error, if the writer is already committed or aborted.
+	Abort() error
+
+	// Written returns the number of bytes written to the item.
+	Written() int64
+}
+
+type itemWriter struct {
+	ctx      context.Context
+	key      string
+	meta     *meta
+	itemPath string
+	metaPath string
+	itemF    StorageFile
+	tags     *tagsIndex
+	crypt    *encryptor
+	storage  Storage
+	unlock   func()
+
+	// out is the writer of the item's data, wrapping the itemF with the encoders.
+	out io.Writer
+	// encoders are the writers wrapping the itemF, from the outermost to the innermost one.
+	encoders []io.WriteCloser
+	// hash is the checksum hash of the item's data, nil if the checksums are disabled.
+	hash hash.Hash
+
+	written int64
+	done    bool
+}
+
+func (w *itemWriter) Write(p []byte) (n int, err error) {
+	if w.done {
+		return 0, ErrWriterClosed
+	}
+
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err = w.out.Write(p)
+	w.written += int64(n)
+
+	if w.hash != nil {
+		_, _ = w.hash.Write(p[:n])
+	}
+
+	return n, err
+}
+
+func (w *itemWriter) Close() error {
+	if w.done {
+		return nil
+	}
+
+	return w.Commit()
+}
+
+func (w *itemWriter) Commit() error {
+	if w.done {
+		return ErrWriterClosed
+	}
+
+	defer w.release()
+
+	if err := w.ctx.Err(); err != nil {
+		w.discard(nil)
+
+		return err
+	}
+
+	if err := w.closeItem(); err != nil {
+		w.discard(nil)
+
+		return err
+	}
+
+	metaF, err := createTempCacheFile(w.storage, w.key, w.metaPath)
+	if err != nil {
+		w.discard(nil)
+
+		return err
+	}
+
+	if err := saveMeta(w.ctx, w.meta, metaF, w.crypt); err != nil {
+		w.discard(metaF)
+
+		return err
+	}
+
+	if err := metaF.Close(); err != nil {
+		w.discard(metaF)
+
+		return fmt.Errorf("failed to close meta file for key %s: %w", w.key, err)
+	}
+
+	// The tags are indexed before publishing, so the published item is always found by its tags.
+	if err := w.tags.add(w.key, w.meta.Tags); err != nil {
+		w.discard(metaF)
+
+		return err
+	}
+
+	var staleTags []string
+
+	if prev, err := readMeta(w.storage, w.key, w.metaPath, w.crypt); err == nil {
+		staleTags = tagsDiff(prev.Tags, w.meta.Tags)
+	}
+
+	if err := util.PublishCacheFiles(w.storage, w.key, w.itemF.Name(), w.itemPath, metaF.Name(), w.metaPath); err != nil {
+		w.discard(metaF)
+
+		return err
+	}
+
+	w.tags.remove(w.key, staleTags)
+
+	return nil
+}
+
+func (w *itemWriter) Abort() error {
+	if w.done {
+		return nil
+	}
+
+	defer w.release()
+
+	w.discard(nil)
+
+	return nil
+}
+
+func (w *itemWriter) Written() int64 {
+	return w.written
+}
+
+// encode wraps the item's output with the encryptor and the codec's encoder.
+func (w *itemWriter) encode(codec Codec) error {
+	if w.crypt != nil {
+		var keyID string
+
+		err := w.wrap(func(out io.Writer) (enc io.WriteCloser, err error) {
+			keyID, enc, err = w.crypt.newWriter(out, w.key)
+
+			return enc, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create encryptor for key %s: %w", w.key, err)
+		}
+
+		w.meta.KeyID = keyID
+	}
+
+	if codec != nil {
+		if err := w.wrap(codec.NewWriter); err != nil {
+			return fmt.Errorf("failed to create %s encoder for key %s: %w", codec.Name(), w.key, err)
+		}
+	}
+
+	return nil
+}
+
+// wrap wraps the item's output with the encoder.
+func (w *itemWriter) wrap(newEncoder func(out io.Writer) (io.WriteCloser, error)) error {
+	enc, err := newEncoder(w.out)
+	if err != nil {
+		return err
+	}
+
+	w.out = enc
+	w.encoders = append([]io.WriteCloser{enc}, w.encoders...)
+
+	return nil
+}
+
+// closeItem completes the item's file and stores its data size, checksum and file's identity in the meta.
+func (w *itemWriter) closeItem() error {
+	if err := w.closeEncoders(); err != nil {
+		return fmt.Errorf("failed to encode cache data for key %s: %w", w.key, err)
+	}
+
+	if err := w.itemF.Close(); err != nil {
+		return fmt.Errorf("failed to close cache file for key %s: %w", w.key, err)
+	}
+
+	// The modification time of the item's file is its creation time, identifying the file with its size.
+	// The file is renamed on publishing, keeping them.
+	_ = w.storage.Chtimes(w.itemF.Name(), w.meta.CreatedAt, w.meta.CreatedAt)
+
+	stat, err := w.storage.Stat(w.itemF.Name())
+	if err != nil {
+		return fmt.Errorf("failed to stat cache file for key %s: %w", w.key, err)
+	}
+
+	w.meta.Size = w.written
+	w.meta.stamp(stat)
+
+	if w.hash != nil {
+		w.meta.Sum = hex.EncodeToString(w.hash.Sum(nil))
+	}
+
+	return nil
+}
+
+// closeEncoders closes the encoders, flushing their data to the item's file.
+func (w *itemWriter) closeEncoders() error {
+	encoders := w.encoders
+	w.encoders = nil
+
+	for _, enc := range encoders {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// discard closes & removes the temporary files.
+func (w *itemWriter) discard(metaF StorageFile) {
+	_ = w.closeEncoders()
+	_ = w.itemF.Close()
+
+	metaTmp := ""
+
+	if metaF != nil {
+		_ = metaF.Close()
+		metaTmp = metaF.Name()
+	}
+
+	util.DeleteCacheFiles(w.storage, w.itemF.Name(), metaTmp)
+}
+
+// release marks the writer as done and unlocks the item's key.
+func (w *itemWriter) release() {
+	w.done = true
+	w.unlock()
+}
+
+// copyToWriter copies the reader's data to the ItemWriter and commits it.
+func copyToWriter(ctx context.Context, w ItemWriter, reader io.Reader) (written int64, err error) {
+	n, err := util.CopyWithCtx(ctx, w, reader)
+	if err != nil {
+		_ = w.Abort()
+
+		return 0, err
+	}
+
+	if err := w.Commit(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
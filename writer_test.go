@@ -0,0 +1,123 @@
+package filecache_test
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/kukymbr/filecache/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemWriter_Commit(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "writer"))
+	require.NoError(t, err)
+
+	w, err := fc.OpenWriter(context.Background(), "test", filecache.ItemOptions{Name: "Name"})
+	require.NoError(t, err)
+
+	gz := gzip.NewWriter(w)
+
+	_, err = gz.Write([]byte("value"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	assert.Greater(t, w.Written(), int64(0))
+	assert.NoError(t, w.Commit())
+
+	_, err = w.Write([]byte("more"))
+	assert.ErrorIs(t, err, filecache.ErrWriterClosed)
+	assert.ErrorIs(t, w.Commit(), filecache.ErrWriterClosed)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Abort())
+
+	res, err := fc.Open(context.Background(), "test")
+	require.NoError(t, err)
+	require.True(t, res.Hit())
+
+	defer func() {
+		_ = res.Reader().Close()
+	}()
+
+	gzr, err := gzip.NewReader(res.Reader())
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(gzr)
+	require.NoError(t, err)
+
+	assert.Equal(t, "value", string(data))
+	assert.Equal(t, "Name", res.Options().Name)
+}
+
+func TestItemWriter_Close(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "writer"))
+	require.NoError(t, err)
+
+	w, err := fc.OpenWriter(context.Background(), "test")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(w, "value")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, "value", string(res.Data()))
+}
+
+func TestItemWriter_Abort(t *testing.T) {
+	target := getTarget(t, "writer")
+
+	fc, err := filecache.New(target, filecache.InstanceOptions{PathGenerator: filecache.FilteredKeyPath})
+	require.NoError(t, err)
+
+	_, err = fc.WriteData(context.Background(), "test", []byte("value1"))
+	require.NoError(t, err)
+
+	w, err := fc.OpenWriter(context.Background(), "test")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(w, "value2")
+	require.NoError(t, err)
+	require.NoError(t, w.Abort())
+
+	assert.ErrorIs(t, w.Commit(), filecache.ErrWriterClosed)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+
+	assert.True(t, res.Hit())
+	assert.Equal(t, "value1", string(res.Data()))
+
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestItemWriter_WhenContextCanceled_ExpectDiscarded(t *testing.T) {
+	fc, err := filecache.New(getTarget(t, "writer"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w, err := fc.OpenWriter(ctx, "test")
+	require.NoError(t, err)
+
+	_, err = io.WriteString(w, "value")
+	require.NoError(t, err)
+
+	cancel()
+
+	_, err = io.WriteString(w, "value")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, w.Commit(), context.Canceled)
+
+	res, err := fc.Read(context.Background(), "test")
+	require.NoError(t, err)
+	assert.False(t, res.Hit())
+}